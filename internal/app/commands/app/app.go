@@ -16,6 +16,8 @@ func All() []cli.Command {
 		List,
 		Start,
 		Stop,
+		Diff,
+		Config,
 	}
 }
 
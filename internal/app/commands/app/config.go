@@ -0,0 +1,285 @@
+package app
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/commands/sandbox"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"github.com/magiconair/properties"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const CONFIG_FILE_EXT = ".cfg"
+
+var configTargetFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "sandbox, s",
+		Usage: "Sandbox to use, defaults to the sandbox of the current project",
+	},
+	cli.StringFlag{
+		Name:  "home",
+		Usage: "XP home folder to use instead of a sandbox",
+	},
+}
+
+var Config = cli.Command{
+	Name:  "config",
+	Usage: "Manage application configuration files of a sandbox",
+	Subcommands: []cli.Command{
+		{
+			Name:      "get",
+			Usage:     "Print the configuration of an application or a single property of it",
+			ArgsUsage: "<app key> [property]",
+			Flags:     append([]cli.Flag{common.FORCE_FLAG}, configTargetFlags...),
+			Action: func(c *cli.Context) error {
+
+				key := ensureAppKeyArg(c)
+				configPath := getConfigPath(resolveConfigHome(c), key)
+
+				content, exists := readConfigFile(configPath)
+				if !exists {
+					fmt.Fprintf(os.Stderr, "No configuration found for \"%s\" in '%s'\n", key, configPath)
+					os.Exit(1)
+				}
+
+				if property := c.Args().Get(1); property != "" {
+					props, err := parseConfig(content)
+					util.Fatal(err, fmt.Sprintf("Configuration file '%s' is not valid:", configPath))
+					value, found := props.Get(property)
+					if !found {
+						fmt.Fprintf(os.Stderr, "Property '%s' is not set\n", property)
+						os.Exit(1)
+					}
+					fmt.Fprintln(os.Stdout, value)
+				} else {
+					fmt.Fprint(os.Stdout, content)
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:      "set",
+			Usage:     "Set one or more properties in the configuration of an application",
+			ArgsUsage: "<app key> <property>=<value>...",
+			Flags:     append([]cli.Flag{common.FORCE_FLAG}, configTargetFlags...),
+			Action: func(c *cli.Context) error {
+
+				key := ensureAppKeyArg(c)
+				assignments := c.Args().Tail()
+				if len(assignments) == 0 {
+					fmt.Fprintln(os.Stderr, "At least one <property>=<value> pair is required")
+					os.Exit(1)
+				}
+				configPath := getConfigPath(resolveConfigHome(c), key)
+				content, _ := readConfigFile(configPath)
+
+				newContent, err := setConfigProperties(content, assignments)
+				util.Fatal(err, "Invalid argument:")
+
+				writeConfigFile(c, configPath, content, newContent)
+
+				return nil
+			},
+		},
+		{
+			Name:      "edit",
+			Usage:     "Edit the configuration of an application in the editor set in VISUAL or EDITOR",
+			ArgsUsage: "<app key>",
+			Flags:     append([]cli.Flag{common.FORCE_FLAG}, configTargetFlags...),
+			Action: func(c *cli.Context) error {
+
+				key := ensureAppKeyArg(c)
+				configPath := getConfigPath(resolveConfigHome(c), key)
+				content, _ := readConfigFile(configPath)
+
+				newContent := editConfigInEditor(key, content)
+
+				writeConfigFile(c, configPath, content, newContent)
+
+				return nil
+			},
+		},
+		{
+			Name:      "diff",
+			Usage:     "Compare the configuration of an application with a local file",
+			ArgsUsage: "<app key> <file>",
+			Flags:     append([]cli.Flag{common.FORCE_FLAG}, configTargetFlags...),
+			Action: func(c *cli.Context) error {
+
+				key := ensureAppKeyArg(c)
+				otherPath := c.Args().Get(1)
+				if otherPath == "" {
+					fmt.Fprintln(os.Stderr, "File to compare with is required")
+					os.Exit(1)
+				}
+				other, err := os.ReadFile(otherPath)
+				util.Fatal(err, "Could not read file:")
+
+				configPath := getConfigPath(resolveConfigHome(c), key)
+				content, _ := readConfigFile(configPath)
+
+				if diff := util.UnifiedDiff(configPath, otherPath, content, string(other)); diff != "" {
+					fmt.Fprint(os.Stdout, diff)
+				} else {
+					fmt.Fprintln(os.Stderr, "No differences found")
+				}
+
+				return nil
+			},
+		},
+	},
+}
+
+func resolveConfigHome(c *cli.Context) string {
+	if home := c.String("home"); home != "" {
+		if info, err := os.Stat(home); err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "XP home folder '%s' does not exist\n", home)
+			os.Exit(1)
+		}
+		return home
+	}
+
+	name := c.String("sandbox")
+	if name == "" && common.HasProjectData(".") {
+		name = common.ReadProjectData(".").Sandbox
+	}
+	box, _ := sandbox.EnsureSandboxExists(c, sandbox.EnsureSandboxOptions{
+		Name:             name,
+		NoBoxMessage:     "No sandboxes found. Create one using 'enonic sandbox create' first.",
+		SelectBoxMessage: "Select sandbox",
+	})
+	if box == nil {
+		os.Exit(1)
+	}
+	return sandbox.GetSandboxHomePath(box.Name)
+}
+
+func getConfigPath(home, key string) string {
+	return filepath.Join(home, "config", key+CONFIG_FILE_EXT)
+}
+
+func readConfigFile(configPath string) (string, bool) {
+	content, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return "", false
+	}
+	util.Fatal(err, fmt.Sprintf("Could not read configuration file '%s':", configPath))
+	return string(content), true
+}
+
+func parseConfig(content string) (*properties.Properties, error) {
+	// loading resolves ${} references too, so unresolvable and circular ones are reported here
+	return properties.LoadString(content)
+}
+
+func writeConfigFile(c *cli.Context, configPath, oldContent, newContent string) {
+	if _, err := parseConfig(newContent); err != nil {
+		fmt.Fprintln(os.Stderr, "Configuration is not valid:", err.Error())
+		os.Exit(1)
+	}
+
+	diff := util.UnifiedDiff(configPath, configPath, oldContent, newContent)
+	if diff == "" {
+		fmt.Fprintln(os.Stderr, "No changes")
+		return
+	}
+	fmt.Fprint(os.Stdout, diff)
+
+	if !common.IsForceMode(c) && !util.PromptBool("Write the changes", true) {
+		return
+	}
+
+	util.Fatal(os.MkdirAll(filepath.Dir(configPath), 0755), "Could not create config folder:")
+	util.Fatal(os.WriteFile(configPath, []byte(newContent), 0644), "Could not write configuration file:")
+	fmt.Fprintf(os.Stderr, "Saved '%s'\n", configPath)
+}
+
+// setConfigProperties replaces values of existing properties in place, keeping comments and ordering, and appends new ones
+func setConfigProperties(content string, assignments []string) (string, error) {
+	lines := strings.Split(content, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	for _, assignment := range assignments {
+		name, value, found := strings.Cut(assignment, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return "", errors.Errorf("'%s' must have the following format <property>=<value>", assignment)
+		}
+		lines = setConfigProperty(lines, name, strings.TrimSpace(value))
+	}
+
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+func setConfigProperty(lines []string, name, value string) []string {
+	keyRegex := regexp.MustCompile(`^\s*` + regexp.QuoteMeta(name) + `(\s*[=:]|\s+|$)`)
+	newLine := name + " = " + value
+
+	for i := 0; i < len(lines); i++ {
+		if !keyRegex.MatchString(lines[i]) {
+			continue
+		}
+		// drop the continuation lines of the old multiline value
+		end := i + 1
+		for line := lines[i]; isContinuedLine(line) && end < len(lines); end++ {
+			line = lines[end]
+		}
+		return append(append(lines[:i:i], newLine), lines[end:]...)
+	}
+	return append(lines, newLine)
+}
+
+func isContinuedLine(line string) bool {
+	trailing := len(line) - len(strings.TrimRight(line, `\`))
+	return trailing%2 == 1
+}
+
+func editConfigInEditor(key, content string) string {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		if util.GetCurrentOs() == "windows" {
+			editor = "notepad"
+		} else {
+			editor = "vi"
+		}
+	}
+
+	tmpFile, err := os.CreateTemp("", key+"-*"+CONFIG_FILE_EXT)
+	util.Fatal(err, "Could not create temporary file:")
+	defer os.Remove(tmpFile.Name())
+	_, err = tmpFile.WriteString(content)
+	tmpFile.Close()
+	util.Fatal(err, "Could not write temporary file:")
+
+	for {
+		editorArgs := strings.Fields(editor)
+		cmd := exec.Command(editorArgs[0], append(editorArgs[1:], tmpFile.Name())...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		util.Fatal(cmd.Run(), fmt.Sprintf("Could not run editor '%s':", editor))
+
+		edited, err := os.ReadFile(tmpFile.Name())
+		util.Fatal(err, "Could not read temporary file:")
+
+		if _, err = parseConfig(string(edited)); err == nil {
+			return string(edited)
+		}
+		fmt.Fprintln(os.Stderr, "Configuration is not valid:", err.Error())
+		if !util.PromptBool("Edit again", true) {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSetConfigProperties(t *testing.T) {
+	content := "# Superhero config\n" +
+		"comments.enabled = false\n" +
+		"title : My blog\n" +
+		"description = first \\\n" +
+		"  second\n" +
+		"footer=true\n"
+
+	result, err := setConfigProperties(content, []string{"comments.enabled=true", "description= short", "new.prop = 42"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "# Superhero config\n" +
+		"comments.enabled = true\n" +
+		"title : My blog\n" +
+		"description = short\n" +
+		"footer=true\n" +
+		"new.prop = 42\n"
+
+	if result != expected {
+		t.Errorf("unexpected content:\n%s\nwant:\n%s", result, expected)
+	}
+}
+
+func TestSetConfigPropertiesDoesNotMatchPrefix(t *testing.T) {
+	result, err := setConfigProperties("title.color = red\n", []string{"title=Blog"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "title.color = red\ntitle = Blog\n" {
+		t.Errorf("unexpected content: %q", result)
+	}
+}
+
+func TestSetConfigPropertiesEmptyFile(t *testing.T) {
+	result, err := setConfigProperties("", []string{"a=1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "a = 1\n" {
+		t.Errorf("unexpected content: %q", result)
+	}
+}
+
+func TestSetConfigPropertiesInvalidAssignment(t *testing.T) {
+	for _, assignment := range []string{"novalue", "=value", " = value"} {
+		if _, err := setConfigProperties("", []string{assignment}); err == nil {
+			t.Errorf("expected an error for %q", assignment)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	if _, err := parseConfig("a = 1\nb = ${a}\n"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := parseConfig("a = ${b}\nb = ${a}\n"); err == nil {
+		t.Error("expected an error for circular reference")
+	}
+	if _, err := parseConfig("a = \\uZZZZ\n"); err == nil {
+		t.Error("expected an error for invalid unicode escape")
+	}
+}
+
+func TestGetConfigPath(t *testing.T) {
+	expected := filepath.Join("xp", "home", "config", "com.enonic.app.superhero.cfg")
+	if got := getConfigPath(filepath.Join("xp", "home"), "com.enonic.app.superhero"); got != expected {
+		t.Errorf("unexpected path: %q", got)
+	}
+}
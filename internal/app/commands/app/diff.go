@@ -0,0 +1,135 @@
+package app
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/commands/remote"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"github.com/urfave/cli"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+const DIFF_MISSING_FROM = "missing in from"
+const DIFF_MISSING_TO = "missing in to"
+const DIFF_VERSION = "version"
+const DIFF_STATE = "state"
+
+var Diff = cli.Command{
+	Name:  "diff",
+	Usage: "Compare applications installed on two remotes",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "from",
+			Usage: "Remote to compare from in the following format: [scheme]://[user:password]@[host]:[port]",
+		},
+		cli.StringFlag{
+			Name:  "to",
+			Usage: "Remote to compare to in the following format: [scheme]://[user:password]@[host]:[port]",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the differences as JSON",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		from := remote.EnsureRemoteFlag(c, "from", common.IsForceMode(c))
+		to := remote.EnsureRemoteFlag(c, "to", common.IsForceMode(c))
+
+		remote.UseRemote(from)
+		fromApps := listApps(c)
+		remote.UseRemote(to)
+		toApps := listApps(c)
+
+		result := AppsDiffResult{
+			From:         from.Url.String(),
+			To:           to.Url.String(),
+			Applications: diffApps(fromApps.Applications, toApps.Applications),
+		}
+
+		if c.Bool("json") {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+		} else {
+			printAppsDiff(os.Stdout, result.Applications)
+		}
+
+		return nil
+	},
+}
+
+func diffApps(from, to []Application) []AppDiff {
+	toByKey := make(map[string]Application, len(to))
+	for _, app := range to {
+		toByKey[app.Key] = app
+	}
+
+	diffs := make([]AppDiff, 0)
+	for i := range from {
+		fromApp := &from[i]
+		toApp, found := toByKey[fromApp.Key]
+		if !found {
+			diffs = append(diffs, AppDiff{Key: fromApp.Key, From: fromApp, Differences: []string{DIFF_MISSING_TO}})
+			continue
+		}
+		delete(toByKey, fromApp.Key)
+
+		var differences []string
+		if fromApp.Version != toApp.Version {
+			differences = append(differences, DIFF_VERSION)
+		}
+		if !strings.EqualFold(fromApp.State, toApp.State) {
+			differences = append(differences, DIFF_STATE)
+		}
+		if len(differences) > 0 {
+			diffs = append(diffs, AppDiff{Key: fromApp.Key, From: fromApp, To: &toApp, Differences: differences})
+		}
+	}
+	for _, app := range toByKey {
+		toApp := app
+		diffs = append(diffs, AppDiff{Key: toApp.Key, To: &toApp, Differences: []string{DIFF_MISSING_FROM}})
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Key < diffs[j].Key
+	})
+	return diffs
+}
+
+func printAppsDiff(out io.Writer, diffs []AppDiff) {
+	if len(diffs) == 0 {
+		fmt.Fprintln(os.Stderr, "No differences found")
+		return
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, strings.Join([]string{"KEY", "FROM", "TO", "DIFFERENCE"}, "\t"))
+	for _, diff := range diffs {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", diff.Key, formatDiffSide(diff.From), formatDiffSide(diff.To), strings.Join(diff.Differences, ", "))
+	}
+	writer.Flush()
+}
+
+func formatDiffSide(app *Application) string {
+	if app == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%s)", app.Version, strings.ToLower(app.State))
+}
+
+type AppsDiffResult struct {
+	From         string    `json:"from"`
+	To           string    `json:"to"`
+	Applications []AppDiff `json:"applications"`
+}
+
+type AppDiff struct {
+	Key         string       `json:"key"`
+	From        *Application `json:"from,omitempty"`
+	To          *Application `json:"to,omitempty"`
+	Differences []string     `json:"differences"`
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffApps(t *testing.T) {
+	from := []Application{
+		{Key: "com.enonic.app.same", Version: "1.0.0", State: "started"},
+		{Key: "com.enonic.app.upgraded", Version: "1.0.0", State: "started"},
+		{Key: "com.enonic.app.stopped", Version: "2.0.0", State: "STARTED"},
+		{Key: "com.enonic.app.fromonly", Version: "3.0.0", State: "started"},
+	}
+	to := []Application{
+		{Key: "com.enonic.app.toonly", Version: "1.0.0", State: "stopped"},
+		{Key: "com.enonic.app.stopped", Version: "2.0.0", State: "stopped"},
+		{Key: "com.enonic.app.upgraded", Version: "1.1.0", State: "stopped"},
+		{Key: "com.enonic.app.same", Version: "1.0.0", State: "STARTED"},
+	}
+
+	diffs := diffApps(from, to)
+
+	if len(diffs) != 4 {
+		t.Fatalf("expected 4 differences, got %d: %+v", len(diffs), diffs)
+	}
+
+	expected := map[string][]string{
+		"com.enonic.app.fromonly": {DIFF_MISSING_TO},
+		"com.enonic.app.stopped":  {DIFF_STATE},
+		"com.enonic.app.toonly":   {DIFF_MISSING_FROM},
+		"com.enonic.app.upgraded": {DIFF_VERSION, DIFF_STATE},
+	}
+	for i, key := range []string{"com.enonic.app.fromonly", "com.enonic.app.stopped", "com.enonic.app.toonly", "com.enonic.app.upgraded"} {
+		if diffs[i].Key != key {
+			t.Errorf("expected %q at position %d, got %q", key, i, diffs[i].Key)
+			continue
+		}
+		if !reflect.DeepEqual(diffs[i].Differences, expected[key]) {
+			t.Errorf("unexpected differences for %q: %v", key, diffs[i].Differences)
+		}
+	}
+
+	if diffs[0].From == nil || diffs[0].To != nil {
+		t.Errorf("expected only 'from' side for %q", diffs[0].Key)
+	}
+	if diffs[2].From != nil || diffs[2].To == nil {
+		t.Errorf("expected only 'to' side for %q", diffs[2].Key)
+	}
+}
+
+func TestDiffAppsIdentical(t *testing.T) {
+	apps := []Application{{Key: "com.enonic.app.same", Version: "1.0.0", State: "started"}}
+
+	diffs := diffApps(apps, apps)
+	if diffs == nil || len(diffs) != 0 {
+		t.Errorf("expected an empty list, got %+v", diffs)
+	}
+}
+
+func TestPrintAppsDiff(t *testing.T) {
+	diffs := diffApps(
+		[]Application{{Key: "com.enonic.app.a", Version: "1.0.0", State: "STARTED"}, {Key: "com.enonic.app.b", Version: "2.0.0", State: "started"}},
+		[]Application{{Key: "com.enonic.app.a", Version: "1.1.0", State: "stopped"}},
+	)
+
+	var out strings.Builder
+	printAppsDiff(&out, diffs)
+
+	expected := "KEY                FROM              TO                DIFFERENCE\n" +
+		"com.enonic.app.a   1.0.0 (started)   1.1.0 (stopped)   version, state\n" +
+		"com.enonic.app.b   2.0.0 (started)   -                 missing in to\n"
+
+	if out.String() != expected {
+		t.Errorf("unexpected table:\n%s\nwant:\n%s", out.String(), expected)
+	}
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"archive/zip"
 	"bytes"
 	"cli-enonic/internal/app/commands/common"
 	"cli-enonic/internal/app/util"
@@ -31,13 +32,55 @@ var Install = cli.Command{
 			Name:  "file",
 			Usage: "Application file",
 		},
+		cli.BoolFlag{
+			Name:  "wait",
+			Usage: "Wait for the application to be started",
+		},
+		cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "How long to wait for the application to be started, e.g. '90s' or '5m'",
+			Value: 2 * time.Minute,
+		},
+		cli.BoolFlag{
+			Name:  "rollback-on-failure",
+			Usage: "Reinstall the previously installed version if the new one is not started in time. Implies --wait",
+		},
 		common.FORCE_FLAG,
 	}, common.AUTH_AND_TLS_FLAGS...),
 	Action: func(c *cli.Context) error {
 
 		file, url := ensureURLOrFileFlag(c)
+		rollback := c.Bool("rollback-on-failure")
+		wait := c.Bool("wait") || rollback
+
+		var installedBefore map[string]Application
+		if rollback {
+			installedBefore = mapAppsByKey(listApps(c).Applications)
+			prepareRollback(file, url, installedBefore)
+		}
 
-		installApp(c, file, url)
+		result := installApp(c, file, url)
+		if !wait {
+			return nil
+		}
+		if result.Failure != "" {
+			os.Exit(1)
+		}
+
+		installed := result.ApplicationInstalledJson.Application
+		if err := waitForAppState(c, installed.Key, installed.Version, STATE_STARTED, c.Duration("timeout")); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			if rollback {
+				rollbackApp(c, installed.Key, installedBefore)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Fprintf(os.Stderr, "Application \"%s\" is started\n", installed.Key)
+		if file != "" {
+			// keep the verified jar so that a failing upgrade later can be rolled back to it
+			util.Warn(cacheAppFile(file, installed.Key, installed.Version), "Could not cache application file:")
+		}
 
 		return nil
 	},
@@ -60,6 +103,185 @@ func installApp(c *cli.Context, file, url string) InstallResult {
 	return result
 }
 
+func rollbackApp(c *cli.Context, key string, installedBefore map[string]Application) {
+	previous, found := installedBefore[key]
+	if !found {
+		fmt.Fprintf(os.Stderr, "Application \"%s\" was not installed before, nothing to roll back to\n", key)
+		return
+	}
+
+	var file, url string
+	if cached := getCachedAppFile(key, previous.Version); cached != "" {
+		file = cached
+	} else if isJarUrl(previous.Url) {
+		url = previous.Url
+	} else {
+		fmt.Fprintf(os.Stderr, "Could not roll back \"%s\" to version %s: it is not cached locally and was not installed from a jar url\n", key, previous.Version)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Rolling back \"%s\" to version %s\n", key, previous.Version)
+	if result := installApp(c, file, url); result.Failure != "" {
+		return
+	}
+	if err := waitForAppState(c, key, previous.Version, previous.State, c.Duration("timeout")); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Rolled back \"%s\" to version %s\n", key, previous.Version)
+}
+
+// prepareRollback keeps the jar of the version the new app replaces in the cache, so that it can be reinstalled
+// even if it was not installed from a file with --wait or its url is gone by the time the upgrade fails
+func prepareRollback(file, url string, installedBefore map[string]Application) {
+	key, err := readNewAppKey(file, url)
+	if err != nil {
+		util.Warn(err, "Could not read application key, previous version can not be fetched for rollback:")
+		return
+	}
+	previous, found := installedBefore[key]
+	if !found || getCachedAppFile(key, previous.Version) != "" {
+		return
+	}
+	if !isJarUrl(previous.Url) {
+		fmt.Fprintf(os.Stderr, "Application \"%s\" version %s was not installed from a jar url, it can only be rolled back to if cached\n", key, previous.Version)
+		return
+	}
+	util.Warn(downloadAppFile(previous.Url, key, previous.Version), fmt.Sprintf("Could not fetch \"%s\" version %s for rollback:", key, previous.Version))
+}
+
+// readNewAppKey reads the key of the app about to be installed from its jar, downloading it if installed from a url
+func readNewAppKey(file, appUrl string) (string, error) {
+	if file != "" {
+		return readAppKey(file)
+	}
+
+	temp, err := os.CreateTemp("", "app-*.jar")
+	if err != nil {
+		return "", err
+	}
+	temp.Close()
+	defer os.Remove(temp.Name())
+
+	if err = downloadFile(appUrl, temp.Name()); err != nil {
+		return "", err
+	}
+	return readAppKey(temp.Name())
+}
+
+// readAppKey reads the application key, the Bundle-SymbolicName without attributes, from the manifest of the jar
+func readAppKey(jar string) (string, error) {
+	archive, err := zip.OpenReader(jar)
+	if err != nil {
+		return "", err
+	}
+	defer archive.Close()
+
+	manifest, err := archive.Open("META-INF/MANIFEST.MF")
+	if err != nil {
+		return "", err
+	}
+	defer manifest.Close()
+	content, err := io.ReadAll(manifest)
+	if err != nil {
+		return "", err
+	}
+
+	// long manifest values continue on the next line after a single space
+	unfolded := strings.ReplaceAll(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n ", "")
+	for _, line := range strings.Split(unfolded, "\n") {
+		if value, found := strings.CutPrefix(line, "Bundle-SymbolicName:"); found {
+			key, _, _ := strings.Cut(value, ";")
+			return strings.TrimSpace(key), nil
+		}
+	}
+	return "", errors.Errorf("'%s' has no Bundle-SymbolicName in its manifest", jar)
+}
+
+// downloadAppFile downloads the jar of the app version into the cache
+func downloadAppFile(appUrl, key, version string) error {
+	if err := os.MkdirAll(getAppCacheDir(), 0755); err != nil {
+		return err
+	}
+	cached := filepath.Join(getAppCacheDir(), getCachedAppFileName(key, version))
+	partFile := cached + common.PART_FILE_EXT
+	if err := downloadFile(appUrl, partFile); err != nil {
+		os.Remove(partFile)
+		return err
+	}
+	return os.Rename(partFile, cached)
+}
+
+func downloadFile(fileUrl, target string) error {
+	res, err := http.Get(fileUrl)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("'%s' responded with %s", fileUrl, res.Status)
+	}
+
+	file, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(file, res.Body); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func mapAppsByKey(apps []Application) map[string]Application {
+	byKey := make(map[string]Application, len(apps))
+	for _, app := range apps {
+		byKey[app.Key] = app
+	}
+	return byKey
+}
+
+func isJarUrl(appUrl string) bool {
+	parsed, err := url.Parse(appUrl)
+	return err == nil && parsed.IsAbs() && strings.HasSuffix(strings.ToLower(parsed.Path), ".jar")
+}
+
+func getAppCacheDir() string {
+	return common.GetInEnonicDir("apps")
+}
+
+func getCachedAppFileName(key, version string) string {
+	return fmt.Sprintf("%s-%s.jar", key, version)
+}
+
+func getCachedAppFile(key, version string) string {
+	cached := filepath.Join(getAppCacheDir(), getCachedAppFileName(key, version))
+	if info, err := os.Stat(cached); err == nil && !info.IsDir() {
+		return cached
+	}
+	return ""
+}
+
+func cacheAppFile(file, key, version string) error {
+	if err := os.MkdirAll(getAppCacheDir(), 0755); err != nil {
+		return err
+	}
+	source, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := os.Create(filepath.Join(getAppCacheDir(), getCachedAppFileName(key, version)))
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	_, err = io.Copy(target, source)
+	return err
+}
+
 func InstallFromFile(c *cli.Context, file string) InstallResult {
 	return installApp(c, file, "")
 }
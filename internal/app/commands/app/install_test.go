@@ -0,0 +1,117 @@
+package app
+
+import (
+	"archive/zip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsJarUrl(t *testing.T) {
+	cases := map[string]bool{
+		"https://repo.enonic.com/public/com/enonic/app/superhero/2.0.5/superhero-2.0.5.jar": true,
+		"https://repo.enonic.com/app.JAR?download=true":                                     true,
+		"https://market.enonic.com/":                                                        false,
+		"superhero-2.0.5.jar":                                                               false,
+		"":                                                                                  false,
+	}
+
+	for appUrl, want := range cases {
+		if got := isJarUrl(appUrl); got != want {
+			t.Errorf("isJarUrl(%q) = %v, want %v", appUrl, got, want)
+		}
+	}
+}
+
+func TestCacheAppFile(t *testing.T) {
+	t.Setenv("ENONIC_CLI_HOME_PATH", t.TempDir())
+
+	jar := filepath.Join(t.TempDir(), "superhero.jar")
+	if err := os.WriteFile(jar, []byte("jar content"), 0644); err != nil {
+		t.Fatalf("write jar: %v", err)
+	}
+
+	if cached := getCachedAppFile("com.enonic.app.superhero", "2.0.5"); cached != "" {
+		t.Fatalf("expected no cached file yet, got %q", cached)
+	}
+
+	if err := cacheAppFile(jar, "com.enonic.app.superhero", "2.0.5"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cached := getCachedAppFile("com.enonic.app.superhero", "2.0.5")
+	if filepath.Base(cached) != "com.enonic.app.superhero-2.0.5.jar" {
+		t.Fatalf("unexpected cached file: %q", cached)
+	}
+	if content, _ := os.ReadFile(cached); string(content) != "jar content" {
+		t.Errorf("unexpected cached content: %q", content)
+	}
+	if other := getCachedAppFile("com.enonic.app.superhero", "2.0.4"); other != "" {
+		t.Errorf("expected no file for another version, got %q", other)
+	}
+}
+
+func TestReadAppKey(t *testing.T) {
+	manifest := "Manifest-Version: 1.0\r\n" +
+		"Bundle-SymbolicName: com.enonic.app.super\r\n hero;singleton:=true\r\n" +
+		"Bundle-Version: 2.0.5\r\n"
+	jar := writeTestJar(t, manifest)
+
+	key, err := readAppKey(jar)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "com.enonic.app.superhero" {
+		t.Errorf("unexpected key %q", key)
+	}
+
+	if _, err = readAppKey(writeTestJar(t, "Manifest-Version: 1.0\r\n")); err == nil {
+		t.Error("expected an error for a manifest without Bundle-SymbolicName")
+	}
+}
+
+func TestDownloadAppFile(t *testing.T) {
+	t.Setenv("ENONIC_CLI_HOME_PATH", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/superhero-2.0.4.jar" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("jar content"))
+	}))
+	defer server.Close()
+
+	if err := downloadAppFile(server.URL+"/superhero-2.0.4.jar", "com.enonic.app.superhero", "2.0.4"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cached := getCachedAppFile("com.enonic.app.superhero", "2.0.4")
+	if content, _ := os.ReadFile(cached); string(content) != "jar content" {
+		t.Errorf("unexpected cached content: %q", content)
+	}
+
+	if err := downloadAppFile(server.URL+"/gone.jar", "com.enonic.app.superhero", "2.0.3"); err == nil {
+		t.Error("expected an error for a missing jar")
+	}
+	if entries, _ := os.ReadDir(getAppCacheDir()); len(entries) != 1 {
+		t.Errorf("expected only the downloaded jar in the cache, got %v", entries)
+	}
+}
+
+func writeTestJar(t *testing.T, manifest string) string {
+	jar := filepath.Join(t.TempDir(), "app.jar")
+	file, err := os.Create(jar)
+	if err != nil {
+		t.Fatalf("create jar: %v", err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	entry, _ := writer.Create("META-INF/MANIFEST.MF")
+	entry.Write([]byte(manifest))
+	if err = writer.Close(); err != nil {
+		t.Fatalf("write jar: %v", err)
+	}
+	return jar
+}
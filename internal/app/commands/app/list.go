@@ -67,18 +67,9 @@ func printApplications(out io.Writer, apps []Application) {
 
 func listApps(c *cli.Context) *ApplicationsResult {
 	// XP has no endpoint that returns the applications, they only arrive as the first event on this stream
-	req := common.CreateRequest(c, "GET", "app/events", nil)
-	req.Header.Set("Accept", common.SSE_CONTENT_TYPE)
-
-	res := common.SendRequest(c, req, "Loading applications")
+	res := openAppEvents(c, "Loading applications", 1)
 	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		var body interface{}
-		common.ParseResponse(res, &body)
-		os.Exit(1) // ParseResponse already exits on a failure response, a 2xx without a stream is unusable too
-	}
-
 	result, err := readApplicationList(res.Body)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Error reading applications: ", err)
@@ -90,6 +81,31 @@ func listApps(c *cli.Context) *ApplicationsResult {
 	return result
 }
 
+// fetchApplicationList reads the applications from a new event stream and closes it, unlike listApps it prints nothing
+func fetchApplicationList(c *cli.Context) (*ApplicationsResult, error) {
+	res := openAppEvents(c, "", 1)
+	defer res.Body.Close()
+	return readApplicationList(res.Body)
+}
+
+func openAppEvents(c *cli.Context, message string, timeoutMin time.Duration) *http.Response {
+	req := common.CreateRequest(c, "GET", "app/events", nil)
+	req.Header.Set("Accept", common.SSE_CONTENT_TYPE)
+
+	res, err := common.SendRequestCustom(c, req, message, timeoutMin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Unable to connect to remote service: ", err)
+		os.Exit(1)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var body interface{}
+		common.ParseResponse(res, &body)
+		os.Exit(1) // ParseResponse already exits on a failure response, a 2xx without a stream is unusable too
+	}
+	return res
+}
+
 func readApplicationList(stream io.Reader) (*ApplicationsResult, error) {
 	reader := common.NewSseReader(stream)
 
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+const STATE_STARTED = "started"
+
+const APP_STATE_POLL_INTERVAL = time.Second
+
+// waitForAppState follows the application events until the given version of the app reaches the state or the timeout expires,
+// an empty version matches any version
+func waitForAppState(c *cli.Context, key, version, state string, timeout time.Duration) error {
+	// the http client timeout covers reading the stream too, so it has to outlive ours
+	res := openAppEvents(c, fmt.Sprintf("Waiting for \"%s\" to be %s", key, state), time.Duration(math.Ceil(timeout.Minutes()))+1)
+	defer res.Body.Close()
+
+	poll := func() (*ApplicationsResult, error) {
+		return fetchApplicationList(c)
+	}
+	doneCh := make(chan error, 1)
+	go func() {
+		doneCh <- readUntilAppState(res.Body, key, version, state, poll)
+	}()
+
+	select {
+	case err := <-doneCh:
+		return err
+	case <-time.After(timeout):
+		return errors.Errorf("application \"%s\" did not reach state '%s' in %s", key, state, timeout)
+	}
+}
+
+// readUntilAppState reads the events until one tells that the version of the app has the state. Events that do not
+// tell the version can be about the version replaced by an install, so the application list is polled instead then.
+func readUntilAppState(stream io.Reader, key, version, state string, poll func() (*ApplicationsResult, error)) error {
+	reader := common.NewSseReader(stream)
+
+	for {
+		event, err := reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				return errors.Errorf("stream ended before application \"%s\" reached state '%s'", key, state)
+			}
+			return err
+		}
+
+		app, found := appFromEvent(event, key)
+		if !found || !strings.EqualFold(app.State, state) {
+			continue
+		}
+		if version == "" || app.Version == version {
+			return nil
+		}
+		if app.Version == "" {
+			return pollAppState(poll, key, version, state)
+		}
+	}
+}
+
+// pollAppState reads the application list until the version of the app has the state
+func pollAppState(poll func() (*ApplicationsResult, error), key, version, state string) error {
+	for {
+		result, err := poll()
+		if err != nil {
+			return err
+		}
+		for _, app := range result.Applications {
+			if app.Key == key && app.Version == version && strings.EqualFold(app.State, state) {
+				return nil
+			}
+		}
+		time.Sleep(APP_STATE_POLL_INTERVAL)
+	}
+}
+
+// appFromEvent returns the application with the key if the event carries its state,
+// the list event has all applications and the others a single one
+func appFromEvent(event *common.SseEvent, key string) (*Application, bool) {
+	if event.Event == LIST_EVENT {
+		var result ApplicationsResult
+		if err := json.Unmarshal([]byte(event.Data), &result); err != nil {
+			return nil, false
+		}
+		for _, app := range result.Applications {
+			if app.Key == key {
+				return &app, true
+			}
+		}
+		return nil, false
+	}
+
+	var app Application
+	if err := json.Unmarshal([]byte(event.Data), &app); err != nil || app.Key != key || app.State == "" {
+		return nil, false
+	}
+	return &app, true
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestReadUntilAppStateFromList(t *testing.T) {
+	if err := readUntilAppState(strings.NewReader(listEventStream), "com.enonic.app.superhero", "", STATE_STARTED, noPoll(t)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestReadUntilAppStateFromStateEvent(t *testing.T) {
+	stream := listEventStream +
+		"event: state\ndata: {\"key\":\"com.enonic.app.superhero\",\"state\":\"stopped\"}\n\n" +
+		"event: state\ndata: {\"key\":\"com.enonic.app.local\",\"state\":\"STARTED\"}\n\n"
+
+	if err := readUntilAppState(strings.NewReader(stream), "com.enonic.app.local", "", STATE_STARTED, noPoll(t)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestReadUntilAppStateStreamEnded(t *testing.T) {
+	err := readUntilAppState(strings.NewReader(listEventStream), "com.enonic.app.local", "", STATE_STARTED, noPoll(t))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "com.enonic.app.local") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestReadUntilAppStateRequiresVersion(t *testing.T) {
+	stream := "event: state\ndata: {\"key\":\"a\",\"version\":\"1.0.0\",\"state\":\"started\"}\n\n" +
+		"event: state\ndata: {\"key\":\"a\",\"version\":\"2.0.0\",\"state\":\"started\"}\n\n"
+
+	if err := readUntilAppState(strings.NewReader(stream), "a", "2.0.0", STATE_STARTED, noPoll(t)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := readUntilAppState(strings.NewReader(stream[:strings.Index(stream, "\n\n")+2]), "a", "2.0.0", STATE_STARTED, noPoll(t)); err == nil {
+		t.Error("expected the previous version not to count")
+	}
+}
+
+func TestReadUntilAppStatePollsForEventWithoutVersion(t *testing.T) {
+	stream := "event: state\ndata: {\"key\":\"a\",\"state\":\"started\"}\n\n"
+	lists := []ApplicationsResult{
+		{Applications: []Application{{Key: "a", Version: "1.0.0", State: "started"}}},
+		{Applications: []Application{{Key: "a", Version: "2.0.0", State: "started"}}},
+	}
+	polls := 0
+	poll := func() (*ApplicationsResult, error) {
+		result := &lists[polls]
+		polls++
+		return result, nil
+	}
+
+	if err := readUntilAppState(strings.NewReader(stream), "a", "2.0.0", STATE_STARTED, poll); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if polls != 2 {
+		t.Errorf("expected the list to be polled until the version started, polled %d times", polls)
+	}
+}
+
+func TestReadUntilAppStatePollError(t *testing.T) {
+	stream := "event: state\ndata: {\"key\":\"a\",\"state\":\"started\"}\n\n"
+	poll := func() (*ApplicationsResult, error) {
+		return nil, errors.New("stream ended")
+	}
+
+	if err := readUntilAppState(strings.NewReader(stream), "a", "2.0.0", STATE_STARTED, poll); err == nil {
+		t.Error("expected the poll error")
+	}
+}
+
+func TestAppFromEvent(t *testing.T) {
+	cases := []struct {
+		name        string
+		event       common.SseEvent
+		wantState   string
+		wantVersion string
+		wantFound   bool
+	}{
+		{"list", common.SseEvent{Event: LIST_EVENT, Data: `{"applications":[{"key":"a","state":"started"}]}`}, "started", "", true},
+		{"list without app", common.SseEvent{Event: LIST_EVENT, Data: `{"applications":[{"key":"b","state":"started"}]}`}, "", "", false},
+		{"state", common.SseEvent{Event: "state", Data: `{"key":"a","state":"stopped"}`}, "stopped", "", true},
+		{"other app", common.SseEvent{Event: "state", Data: `{"key":"b","state":"stopped"}`}, "", "", false},
+		{"no state", common.SseEvent{Event: "uninstalled", Data: `{"key":"a"}`}, "", "", false},
+		{"invalid", common.SseEvent{Event: "state", Data: `not json`}, "", "", false},
+		{"list with version", common.SseEvent{Event: LIST_EVENT, Data: `{"applications":[{"key":"a","version":"2.0.0","state":"started"}]}`}, "started", "2.0.0", true},
+		{"state with version", common.SseEvent{Event: "state", Data: `{"key":"a","version":"1.0.0","state":"started"}`}, "started", "1.0.0", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			app, found := appFromEvent(&tc.event, "a")
+			if found != tc.wantFound {
+				t.Fatalf("appFromEvent() found = %v, want %v", found, tc.wantFound)
+			}
+			if found && (app.State != tc.wantState || app.Version != tc.wantVersion) {
+				t.Errorf("appFromEvent() = (%q, %q), want (%q, %q)", app.State, app.Version, tc.wantState, tc.wantVersion)
+			}
+		})
+	}
+}
+
+// noPoll fails the test if the application list is polled
+func noPoll(t *testing.T) func() (*ApplicationsResult, error) {
+	return func() (*ApplicationsResult, error) {
+		t.Error("unexpected poll of the application list")
+		return nil, errors.New("unexpected poll")
+	}
+}
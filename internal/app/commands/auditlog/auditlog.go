@@ -5,5 +5,7 @@ import "github.com/urfave/cli"
 func All() []cli.Command {
 	return []cli.Command{
 		Cleanup,
+		Search,
+		Export,
 	}
 }
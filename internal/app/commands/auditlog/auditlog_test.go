@@ -0,0 +1,156 @@
+package auditlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	for value, want := range map[string]string{
+		"2024-01-31":           "2024-01-31T00:00:00Z",
+		"2024-01-31T08:30:00Z": "2024-01-31T08:30:00Z",
+		"P7D":                  "2024-03-03T12:00:00Z",
+		"PT2H":                 "2024-03-10T10:00:00Z",
+	} {
+		got, err := parseTime(value, now)
+		if err != nil || got.UTC().Format(time.RFC3339) != want {
+			t.Errorf("%s: got %v %v, want %s", value, got, err, want)
+		}
+	}
+	if got, err := parseTime("", now); got != nil || err != nil {
+		t.Errorf("expected no time, got %v %v", got, err)
+	}
+	if _, err := parseTime("yesterday", now); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	now := time.Now()
+	filter, err := parseFilter("system.content.publish, system.content.delete", "user:system:alice", "P7D", "", "", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(filter.Types) != 2 || len(filter.Users) != 1 || filter.From == nil || filter.To != nil {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+	if _, err = parseFilter("", "", "2024-02-01", "2024-01-01", "", now); err == nil || !strings.Contains(err.Error(), "before") {
+		t.Errorf("expected an order error, got %v", err)
+	}
+}
+
+func testRecords(count int) []Record {
+	records := make([]Record, count)
+	for i := range records {
+		records[i] = Record{
+			Id:      string(rune('a' + i)),
+			Type:    "system.content.publish",
+			Time:    time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC),
+			User:    "user:system:alice",
+			Objects: []string{"com.enonic.cms.default:draft:1", "com.enonic.cms.default:master:1"},
+		}
+	}
+	records[0].Data = map[string]interface{}{"params": map[string]interface{}{"message": "a, \"b\""}}
+	return records
+}
+
+// fakeAuditLog finds records like the server does: newest first, before the exclusive upper bound
+// which it reads with millisecond precision
+func fakeAuditLog(records []Record, calls *[]int) func(page *RecordFilter, start int) (*RecordsResult, error) {
+	return func(page *RecordFilter, start int) (*RecordsResult, error) {
+		*calls = append(*calls, start)
+		matching := make([]Record, 0)
+		for _, record := range records {
+			if page.To == nil || record.Time.Before(page.To.Truncate(time.Millisecond)) {
+				matching = append(matching, record)
+			}
+		}
+		sort.SliceStable(matching, func(i, j int) bool {
+			return matching[i].Time.After(matching[j].Time)
+		})
+		end := start + 2
+		if end > len(matching) {
+			end = len(matching)
+		}
+		if start > end {
+			start = end
+		}
+		return &RecordsResult{Total: int64(len(matching)), Hits: matching[start:end]}, nil
+	}
+}
+
+func TestExportRecordsPagesByTime(t *testing.T) {
+	records := testRecords(7)
+	// several records at the same time, more than fit in a page
+	for _, i := range []int{2, 3, 4} {
+		records[i].Time = records[1].Time
+	}
+	records[4].Time = records[4].Time.Add(300 * time.Microsecond)
+	to := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	calls := make([]int, 0)
+	fetch := fakeAuditLog(records, &calls)
+	added := false
+	counted := func(page *RecordFilter, start int) (*RecordsResult, error) {
+		if !added {
+			// written while exporting, after the pinned upper bound
+			records = append(records, Record{Id: "new", Time: to.Add(time.Second)})
+			added = true
+		}
+		return fetch(page, start)
+	}
+
+	var out bytes.Buffer
+	exported, err := exportRecords(&out, FORMAT_JSONL, &RecordFilter{To: &to}, 2, counted, func(int, int64) {})
+	if err != nil || exported != 7 {
+		t.Fatalf("got %d records, %v", exported, err)
+	}
+	ids := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var record Record
+		json.Unmarshal([]byte(line), &record)
+		ids = append(ids, record.Id)
+	}
+	sort.Strings(ids)
+	if strings.Join(ids, "") != "abcdefg" {
+		t.Errorf("expected every record once, got %v", ids)
+	}
+	// offsets only skip the records at the time of the page bound, never the records written before
+	if fmt.Sprint(calls) != "[0 1 2 4]" {
+		t.Errorf("unexpected offsets %v", calls)
+	}
+}
+
+func TestExportRecordsCsv(t *testing.T) {
+	records := testRecords(1)
+	calls := make([]int, 0)
+
+	var out bytes.Buffer
+	if _, err := exportRecords(&out, FORMAT_CSV, &RecordFilter{}, 2, fakeAuditLog(records, &calls), func(int, int64) {}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "id,time,type,source,user,objects,data\n" +
+		`a,2024-01-01T00:00:00Z,system.content.publish,,user:system:alice,com.enonic.cms.default:draft:1 com.enonic.cms.default:master:1,"{""params"":{""message"":""a, \""b\""""}}"` + "\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if exported, _ := exportRecords(&out, FORMAT_CSV, &RecordFilter{}, 2, fakeAuditLog(nil, &calls), func(int, int64) {}); exported != 0 || out.String() != "id,time,type,source,user,objects,data\n" {
+		t.Errorf("unexpected empty export %q", out.String())
+	}
+}
+
+func TestPrintRecords(t *testing.T) {
+	var out bytes.Buffer
+	printRecords(&out, testRecords(1))
+	want := "TIME                   TYPE                     USER                OBJECTS\n2024-01-01T00:00:00Z   system.content.publish   user:system:alice   com.enonic.cms.default:draft:1, com.enonic.cms.default:master:1\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
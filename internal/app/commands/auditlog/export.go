@@ -0,0 +1,212 @@
+package auditlog
+
+import (
+	"bufio"
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+const FORMAT_JSONL = "jsonl"
+const FORMAT_CSV = "csv"
+const EXPORT_PAGE_SIZE = 500
+
+var csvHeader = []string{"id", "time", "type", "source", "user", "objects", "data"}
+
+var Export = cli.Command{
+	Name:  "export",
+	Usage: "Export all matching audit log records as JSON lines or CSV.",
+	Flags: append(append(append([]cli.Flag{}, FILTER_FLAGS...),
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "Format of the export: jsonl or csv",
+			Value: FORMAT_JSONL,
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "File to write the records to, defaults to the standard output",
+		},
+		common.FORCE_FLAG,
+	), common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		now := time.Now()
+		filter := ensureFilterFlags(c, now)
+		if filter.To == nil {
+			// records written while exporting are left out, so that they do not shift the pages
+			filter.To = &now
+		}
+		format := strings.ToLower(c.String("format"))
+		if format != FORMAT_JSONL && format != FORMAT_CSV {
+			fmt.Fprintf(os.Stderr, "Unknown format '%s', use jsonl or csv\n", c.String("format"))
+			os.Exit(1)
+		}
+
+		fetch := func(page *RecordFilter, start int) (*RecordsResult, error) {
+			return fetchRecords(c, page, start, EXPORT_PAGE_SIZE)
+		}
+		progress := func(exported int, total int64) {
+			fmt.Fprintf(os.Stderr, "\rExported %d of %d records", exported, total)
+		}
+
+		output := c.String("output")
+		if output == "" {
+			out := bufio.NewWriter(os.Stdout)
+			exported, err := exportRecords(out, format, filter, EXPORT_PAGE_SIZE, fetch, progress)
+			if err == nil {
+				err = out.Flush()
+			}
+			fmt.Fprintln(os.Stderr)
+			util.Fatal(err, "Could not export audit log:")
+			fmt.Fprintf(os.Stderr, "Exported %d records\n", exported)
+			return nil
+		}
+
+		common.EnsureOverwrite(c, output)
+		// records are written to a partial file first, so that an interrupted export does not look complete
+		partFile := output + common.PART_FILE_EXT
+		file, err := os.Create(partFile)
+		util.Fatal(err, "Could not create export file:")
+
+		out := bufio.NewWriter(file)
+		exported, err := exportRecords(out, format, filter, EXPORT_PAGE_SIZE, fetch, progress)
+		if err == nil {
+			err = out.Flush()
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(partFile, output)
+		}
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			os.Remove(partFile)
+			util.Fatal(err, "Could not export audit log:")
+		}
+
+		fmt.Fprintf(os.Stderr, "Exported %d records to '%s'\n", exported, output)
+		return nil
+	},
+}
+
+// exportRecords writes the matching records page by page, newest first, before fetching the next page,
+// so that the whole audit log is never kept in memory. Pages are not read by offset, which the server limits
+// and which shifts when records are added, but by moving the upper time bound to the millisecond of the oldest
+// record written so far. Only the records in that millisecond that were written already are skipped with the offset.
+func exportRecords(out io.Writer, format string, filter *RecordFilter, pageSize int, fetch func(page *RecordFilter, start int) (*RecordsResult, error), progress func(exported int, total int64)) (int, error) {
+	writer := newRecordWriter(out, format)
+	page := *filter
+	exported, skip := 0, 0
+	var total int64 = -1
+	var oldest time.Time
+	for {
+		result, err := fetch(&page, skip)
+		if err != nil {
+			return exported, err
+		}
+		if total < 0 {
+			total = result.Total
+		}
+		for _, record := range result.Hits {
+			if err = writer.write(record); err != nil {
+				return exported, err
+			}
+			exported++
+			// records are counted per millisecond, the precision the server keeps time with
+			if millisecond := record.Time.Truncate(time.Millisecond); millisecond.Equal(oldest) {
+				skip++
+			} else {
+				oldest = millisecond
+				skip = 1
+			}
+		}
+		if err = writer.flush(); err != nil {
+			return exported, err
+		}
+		progress(exported, total)
+
+		if len(result.Hits) < pageSize {
+			return exported, nil
+		}
+		// the upper bound is exclusive, so the next page starts with the millisecond of the oldest record
+		to := oldest.Add(time.Millisecond)
+		page.To = &to
+	}
+}
+
+func newRecordWriter(out io.Writer, format string) recordWriter {
+	if format == FORMAT_CSV {
+		return &csvRecordWriter{writer: csv.NewWriter(out)}
+	}
+	return &jsonlRecordWriter{encoder: json.NewEncoder(out)}
+}
+
+type recordWriter interface {
+	write(record Record) error
+	flush() error
+}
+
+type jsonlRecordWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonlRecordWriter) write(record Record) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *jsonlRecordWriter) flush() error {
+	return nil
+}
+
+type csvRecordWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+// write puts the objects of the record in one column separated by spaces and its data as JSON
+func (w *csvRecordWriter) write(record Record) error {
+	if !w.headerWritten {
+		if err := w.writer.Write(csvHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	data := ""
+	if len(record.Data) > 0 {
+		encoded, err := json.Marshal(record.Data)
+		if err != nil {
+			return err
+		}
+		data = string(encoded)
+	}
+	return w.writer.Write([]string{
+		record.Id,
+		record.Time.UTC().Format(time.RFC3339Nano),
+		record.Type,
+		record.Source,
+		record.User,
+		strings.Join(record.Objects, " "),
+		data,
+	})
+}
+
+func (w *csvRecordWriter) flush() error {
+	if !w.headerWritten {
+		// an empty export still gets the header
+		if err := w.writer.Write(csvHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
@@ -0,0 +1,216 @@
+package auditlog
+
+import (
+	"bytes"
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/senseyeio/duration"
+	"github.com/urfave/cli"
+)
+
+const DEFAULT_PAGE_SIZE = 20
+const DATE_FORMAT = "2006-01-02"
+
+var FILTER_FLAGS = []cli.Flag{
+	cli.StringFlag{
+		Name:  "type",
+		Usage: "A comma-separated list of record types, e.g. 'system.content.publish,system.content.delete'",
+	},
+	cli.StringFlag{
+		Name:  "user",
+		Usage: "A comma-separated list of users that made the changes, e.g. 'user:system:alice'",
+	},
+	cli.StringFlag{
+		Name:  "from",
+		Usage: "Only records from this time: a date (2024-01-31), a time (2024-01-31T12:00:00Z) or an ISO-8601 duration back from now (P7D)",
+	},
+	cli.StringFlag{
+		Name:  "to",
+		Usage: "Only records before this time, in the same formats as --from",
+	},
+	cli.StringFlag{
+		Name:  "object",
+		Usage: "A comma-separated list of changed objects, e.g. 'com.enonic.cms.default:draft:<node id>'",
+	},
+}
+
+var Search = cli.Command{
+	Name:  "search",
+	Usage: "Find audit log records by type, user, time and changed object.",
+	Flags: append(append(append([]cli.Flag{}, FILTER_FLAGS...),
+		cli.IntFlag{
+			Name:  "start",
+			Usage: "Index of the first record to show",
+		},
+		cli.IntFlag{
+			Name:  "count",
+			Usage: "Number of records to show",
+			Value: DEFAULT_PAGE_SIZE,
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the records as JSON",
+		},
+		common.FORCE_FLAG,
+	), common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		filter := ensureFilterFlags(c, time.Now())
+		if c.Int("start") < 0 || c.Int("count") < 1 {
+			fmt.Fprintln(os.Stderr, "--start can not be negative and --count has to be 1 or more")
+			os.Exit(1)
+		}
+
+		result := findRecords(c, filter, c.Int("start"), c.Int("count"), "Searching audit log")
+
+		if c.Bool("json") {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+		} else {
+			printRecords(os.Stdout, result.Hits)
+			common.PrintPage(os.Stderr, "records", c.Int("start"), len(result.Hits), result.Total)
+		}
+
+		return nil
+	},
+}
+
+// ensureFilterFlags validates the filter flags, times are resolved against now
+func ensureFilterFlags(c *cli.Context, now time.Time) *RecordFilter {
+	filter, err := parseFilter(c.String("type"), c.String("user"), c.String("from"), c.String("to"), c.String("object"), now)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return filter
+}
+
+func parseFilter(types, users, from, to, objects string, now time.Time) (*RecordFilter, error) {
+	filter := &RecordFilter{
+		Types:   common.SplitList(types),
+		Users:   common.SplitList(users),
+		Objects: common.SplitList(objects),
+	}
+	var err error
+	if filter.From, err = parseTime(from, now); err != nil {
+		return nil, errors.Wrap(err, "Not a valid --from")
+	}
+	if filter.To, err = parseTime(to, now); err != nil {
+		return nil, errors.Wrap(err, "Not a valid --to")
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, errors.New("--from has to be before --to")
+	}
+	return filter, nil
+}
+
+// parseTime reads a date, a time or an ISO-8601 duration that is counted back from now
+func parseTime(value string, now time.Time) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	if t, err := time.ParseInLocation(DATE_FORMAT, value, now.Location()); err == nil {
+		return &t, nil
+	}
+	if d, err := duration.ParseISO8601(value); err == nil {
+		back := duration.Duration{Y: -d.Y, M: -d.M, W: -d.W, D: -d.D, TH: -d.TH, TM: -d.TM, TS: -d.TS}
+		t := back.Shift(now)
+		return &t, nil
+	}
+	return nil, errors.Errorf("time '%s', use a date (2024-01-31), a time (2024-01-31T12:00:00Z) or a duration (P7D)", value)
+}
+
+func findRecords(c *cli.Context, filter *RecordFilter, start, count int, message string) *RecordsResult {
+	res := common.SendRequest(c, createFindRequest(c, filter, start, count), message)
+
+	var result RecordsResult
+	common.ParseResponse(res, &result)
+	return &result
+}
+
+// fetchRecords works like findRecords but returns errors instead of exiting, so that callers can clean up first
+func fetchRecords(c *cli.Context, filter *RecordFilter, start, count int) (*RecordsResult, error) {
+	res, err := common.SendRequestCustom(c, createFindRequest(c, filter, start, count), "", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var result RecordsResult
+	if enonicErr, err := common.ParseResponseCustom(res, &result); enonicErr != nil {
+		return nil, errors.New(enonicErr.Message)
+	} else if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func createFindRequest(c *cli.Context, filter *RecordFilter, start, count int) *http.Request {
+	body := new(bytes.Buffer)
+	params := map[string]interface{}{
+		"start": start,
+		"count": count,
+	}
+	if len(filter.Types) > 0 {
+		params["type"] = filter.Types
+	}
+	if len(filter.Users) > 0 {
+		params["users"] = filter.Users
+	}
+	if len(filter.Objects) > 0 {
+		params["objects"] = filter.Objects
+	}
+	if filter.From != nil {
+		params["from"] = filter.From.UTC().Format(time.RFC3339Nano)
+	}
+	if filter.To != nil {
+		params["to"] = filter.To.UTC().Format(time.RFC3339Nano)
+	}
+	json.NewEncoder(body).Encode(params)
+
+	return common.CreateRequest(c, "POST", "auditlog/find", body)
+}
+
+func printRecords(out io.Writer, records []Record) {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, "TIME\tTYPE\tUSER\tOBJECTS")
+	for _, record := range records {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", record.Time.Format(time.RFC3339), record.Type, record.User, strings.Join(record.Objects, ", "))
+	}
+	writer.Flush()
+}
+
+type RecordFilter struct {
+	Types   []string
+	Users   []string
+	Objects []string
+	From    *time.Time
+	To      *time.Time
+}
+
+type RecordsResult struct {
+	Total int64    `json:"total"`
+	Hits  []Record `json:"hits"`
+}
+
+type Record struct {
+	Id      string                 `json:"id"`
+	Type    string                 `json:"type"`
+	Time    time.Time              `json:"time"`
+	Source  string                 `json:"source"`
+	User    string                 `json:"user"`
+	Objects []string               `json:"objects"`
+	Data    map[string]interface{} `json:"data"`
+}
@@ -7,5 +7,8 @@ import (
 func All() []cli.Command {
 	return []cli.Command{
 		Reprocess,
+		Project,
+		Publish,
+		Unpublish,
 	}
 }
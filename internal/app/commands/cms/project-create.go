@@ -0,0 +1,186 @@
+package cms
+
+import (
+	"bytes"
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v3"
+)
+
+const READ_ACCESS_PUBLIC = "public"
+const READ_ACCESS_PRIVATE = "private"
+
+var ProjectCreate = cli.Command{
+	Name:      "create",
+	Usage:     "Create a content project, from flags or a YAML file.",
+	ArgsUsage: "<project id>",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "file",
+			Usage: "YAML file with the project definition, flags override its values",
+		},
+		cli.StringFlag{
+			Name:  "display-name",
+			Usage: "Display name of the project, defaults to the project id",
+		},
+		cli.StringFlag{
+			Name:  "description",
+			Usage: "Description of the project",
+		},
+		cli.StringFlag{
+			Name:  "language",
+			Usage: "Default language of the content, e.g. 'en' or 'no-NB'",
+		},
+		cli.StringFlag{
+			Name:  "parent",
+			Usage: "Id of the parent project to inherit content from",
+		},
+		cli.StringFlag{
+			Name:  "read-access",
+			Usage: "Who can read the published content: public or private",
+		},
+		cli.StringFlag{
+			Name:  "owner",
+			Usage: "A comma-separated list of principals with the owner role, e.g. 'user:system:alice,group:system:admins'",
+		},
+		cli.StringFlag{
+			Name:  "editor",
+			Usage: "A comma-separated list of principals with the editor role",
+		},
+		cli.StringFlag{
+			Name:  "author",
+			Usage: "A comma-separated list of principals with the author role",
+		},
+		cli.StringFlag{
+			Name:  "contributor",
+			Usage: "A comma-separated list of principals with the contributor role",
+		},
+		cli.StringFlag{
+			Name:  "viewer",
+			Usage: "A comma-separated list of principals with the viewer role",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		project := &ProjectDefinition{}
+		if file := c.String("file"); file != "" {
+			var err error
+			project, err = readProjectFile(file)
+			util.Fatal(err, "Could not read project file:")
+		}
+		applyProjectFlags(c, project)
+
+		if project.Id == "" {
+			project.Id = ensureProjectIdArg(c, "Enter id of the project to create")
+		}
+		if project.DisplayName == "" {
+			if common.IsForceMode(c) {
+				project.DisplayName = project.Id
+			} else {
+				project.DisplayName = util.PromptString("Enter display name", "", project.Id, nil)
+			}
+		}
+		if err := validateProject(project); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		req := createProjectRequest(c, "content/project/create", project)
+		res := common.SendRequest(c, req, fmt.Sprintf("Creating project '%s'", project.Id))
+
+		var result ProjectDefinition
+		common.ParseResponse(res, &result)
+		fmt.Fprintf(os.Stderr, "Created project '%s'\n", project.Id)
+		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+
+		return nil
+	},
+}
+
+func readProjectFile(path string) (*ProjectDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var project ProjectDefinition
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(&project); err != nil && err != io.EOF {
+		return nil, errors.Wrapf(err, "invalid project definition '%s'", path)
+	}
+	return &project, nil
+}
+
+// applyProjectFlags overrides the project definition with the argument and the flags that are set
+func applyProjectFlags(c *cli.Context, project *ProjectDefinition) {
+	if id := strings.TrimSpace(c.Args().First()); id != "" {
+		project.Id = id
+	}
+	for flag, value := range map[string]*string{
+		"display-name": &project.DisplayName,
+		"description":  &project.Description,
+		"language":     &project.Language,
+		"parent":       &project.Parent,
+		"read-access":  &project.ReadAccess,
+	} {
+		if c.IsSet(flag) {
+			*value = strings.TrimSpace(c.String(flag))
+		}
+	}
+	for flag, principals := range map[string]*[]string{
+		"owner":       &project.Permissions.Owner,
+		"editor":      &project.Permissions.Editor,
+		"author":      &project.Permissions.Author,
+		"contributor": &project.Permissions.Contributor,
+		"viewer":      &project.Permissions.Viewer,
+	} {
+		if c.IsSet(flag) {
+			*principals = common.SplitList(c.String(flag))
+		}
+	}
+}
+
+func validateProject(project *ProjectDefinition) error {
+	if err := validateProjectId(project.Id); err != nil {
+		return errors.New(strings.TrimSuffix(err.Error(), ": "))
+	}
+	if project.Parent != "" {
+		if err := validateProjectId(project.Parent); err != nil {
+			return errors.Errorf("Not a valid parent project id '%s'", project.Parent)
+		}
+		if project.Parent == project.Id {
+			return errors.New("Project can not be its own parent")
+		}
+	}
+	if project.Language != "" && !languagePattern.MatchString(project.Language) {
+		return errors.Errorf("Not a valid language '%s', use a language tag like 'en' or 'no-NB'", project.Language)
+	}
+	if project.ReadAccess != "" && project.ReadAccess != READ_ACCESS_PUBLIC && project.ReadAccess != READ_ACCESS_PRIVATE {
+		return errors.Errorf("Not a valid read access '%s', use %s or %s", project.ReadAccess, READ_ACCESS_PUBLIC, READ_ACCESS_PRIVATE)
+	}
+	for _, role := range project.Permissions.byRole() {
+		for _, principal := range role.principals {
+			if !principalPattern.MatchString(principal) {
+				return errors.Errorf("Not a valid %s principal '%s', use user:<id provider>:<id>, group:<id provider>:<id> or role:<id>", role.name, principal)
+			}
+		}
+	}
+	return nil
+}
+
+func createProjectRequest(c *cli.Context, url string, params interface{}) *http.Request {
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(params)
+
+	return common.CreateRequest(c, "POST", url, body)
+}
@@ -0,0 +1,246 @@
+package cms
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const DEFAULT_PROJECT = "default"
+const PROJECT_REPO_PREFIX = "com.enonic.cms."
+
+// same rules as XP applies to project names
+var projectIdPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_\-]*$`)
+var principalPattern = regexp.MustCompile(`^(user:[^:\s]+:[^:\s]+|group:[^:\s]+:[^:\s]+|role:[^:\s]+)$`)
+var languagePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]+)*$`)
+
+var Project = cli.Command{
+	Name:  "project",
+	Usage: "Create, list, show and delete content projects.",
+	Subcommands: []cli.Command{
+		ProjectCreate,
+		ProjectList,
+		ProjectShow,
+		ProjectDelete,
+	},
+}
+
+var ProjectList = cli.Command{
+	Name:    "list",
+	Aliases: []string{"ls"},
+	Usage:   "List content projects.",
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the projects as JSON",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		req := common.CreateRequest(c, "GET", "content/project/list", nil)
+		res := common.SendRequest(c, req, "Loading projects")
+
+		var result ProjectsResult
+		common.ParseResponse(res, &result)
+
+		if c.Bool("json") {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+		} else {
+			printProjects(os.Stdout, result.Projects)
+		}
+
+		return nil
+	},
+}
+
+var ProjectShow = cli.Command{
+	Name:      "show",
+	Usage:     "Show settings and permissions of a content project.",
+	ArgsUsage: "<project id>",
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the project as JSON",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		project := fetchProject(c, ensureProjectIdArg(c, "Enter project id"))
+
+		if c.Bool("json") {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(project))
+		} else {
+			printProject(os.Stdout, project)
+		}
+
+		return nil
+	},
+}
+
+var ProjectDelete = cli.Command{
+	Name:      "delete",
+	Aliases:   []string{"del"},
+	Usage:     "Delete a content project with all its content.",
+	ArgsUsage: "<project id>",
+	Flags:     append([]cli.Flag{common.FORCE_FLAG}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		projectId := ensureProjectIdArg(c, "Enter id of the project to delete")
+		if projectId == DEFAULT_PROJECT {
+			fmt.Fprintf(os.Stderr, "Project '%s' can not be deleted\n", DEFAULT_PROJECT)
+			os.Exit(1)
+		}
+
+		if !common.IsForceMode(c) {
+			project := fetchProject(c, projectId)
+			printProject(os.Stderr, project)
+			fmt.Fprintln(os.Stderr)
+			if !util.PromptBool(fmt.Sprintf("Delete project '%s' and repository '%s' with all its content", projectId, PROJECT_REPO_PREFIX+projectId), false) {
+				os.Exit(1)
+			}
+		}
+
+		req := createProjectRequest(c, "content/project/delete", map[string]interface{}{
+			"id": projectId,
+		})
+		res := common.SendRequest(c, req, fmt.Sprintf("Deleting project '%s'", projectId))
+
+		var result DeleteProjectResponse
+		common.ParseResponse(res, &result)
+		fmt.Fprintf(os.Stderr, "Deleted project '%s'\n", projectId)
+		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+
+		return nil
+	},
+}
+
+func fetchProject(c *cli.Context, projectId string) *ProjectDefinition {
+	req := common.CreateRequest(c, "GET", "content/project/get?id="+url.QueryEscape(projectId), nil)
+	res := common.SendRequest(c, req, "Loading project")
+
+	var project ProjectDefinition
+	common.ParseResponse(res, &project)
+	return &project
+}
+
+func ensureProjectIdArg(c *cli.Context, message string) string {
+	if common.IsForceMode(c) && c.Args().First() == "" {
+		fmt.Fprintln(os.Stderr, "Project id can not be empty in non-interactive mode.")
+		os.Exit(1)
+	}
+	validator := func(val interface{}) error {
+		return validateProjectId(strings.TrimSpace(val.(string)))
+	}
+	return strings.TrimSpace(util.PromptString(message, c.Args().First(), "", validator))
+}
+
+func validateProjectId(projectId string) error {
+	if projectId == "" {
+		return errors.New("Project id can not be empty: ")
+	}
+	if !projectIdPattern.MatchString(projectId) {
+		return errors.Errorf("Not a valid project id '%s'. Use lowercase letters, digits, '-' and '_': ", projectId)
+	}
+	return nil
+}
+
+func printProjects(out io.Writer, projects []ProjectDefinition) {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, "ID\tDISPLAY NAME\tLANGUAGE\tPARENT")
+	for _, project := range projects {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", project.Id, project.DisplayName, project.Language, project.Parent)
+	}
+	writer.Flush()
+}
+
+func printProject(out io.Writer, project *ProjectDefinition) {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(writer, "Id:\t%s\n", project.Id)
+	fmt.Fprintf(writer, "Display name:\t%s\n", project.DisplayName)
+	if project.Description != "" {
+		fmt.Fprintf(writer, "Description:\t%s\n", project.Description)
+	}
+	if project.Language != "" {
+		fmt.Fprintf(writer, "Language:\t%s\n", project.Language)
+	}
+	if project.Parent != "" {
+		fmt.Fprintf(writer, "Parent:\t%s\n", project.Parent)
+	}
+	if project.ReadAccess != "" {
+		fmt.Fprintf(writer, "Read access:\t%s\n", project.ReadAccess)
+	}
+	writer.Flush()
+
+	roles := project.Permissions.byRole()
+	if len(roles) == 0 {
+		return
+	}
+	fmt.Fprintln(out)
+	writer = tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, "ROLE\tPRINCIPAL")
+	for _, role := range roles {
+		for _, principal := range role.principals {
+			fmt.Fprintf(writer, "%s\t%s\n", role.name, principal)
+		}
+	}
+	writer.Flush()
+}
+
+// byRole lists the roles that have principals, from the most to the least privileged
+func (p ProjectPermissions) byRole() []projectRole {
+	roles := make([]projectRole, 0)
+	for _, role := range []projectRole{
+		{"owner", p.Owner},
+		{"editor", p.Editor},
+		{"author", p.Author},
+		{"contributor", p.Contributor},
+		{"viewer", p.Viewer},
+	} {
+		if len(role.principals) > 0 {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+type projectRole struct {
+	name       string
+	principals []string
+}
+
+type ProjectDefinition struct {
+	Id          string             `json:"id" yaml:"id"`
+	DisplayName string             `json:"displayName" yaml:"displayName"`
+	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Language    string             `json:"language,omitempty" yaml:"language,omitempty"`
+	Parent      string             `json:"parent,omitempty" yaml:"parent,omitempty"`
+	ReadAccess  string             `json:"readAccess,omitempty" yaml:"readAccess,omitempty"`
+	Permissions ProjectPermissions `json:"permissions" yaml:"permissions,omitempty"`
+}
+
+type ProjectPermissions struct {
+	Owner       []string `json:"owner,omitempty" yaml:"owner,omitempty"`
+	Editor      []string `json:"editor,omitempty" yaml:"editor,omitempty"`
+	Author      []string `json:"author,omitempty" yaml:"author,omitempty"`
+	Contributor []string `json:"contributor,omitempty" yaml:"contributor,omitempty"`
+	Viewer      []string `json:"viewer,omitempty" yaml:"viewer,omitempty"`
+}
+
+type ProjectsResult struct {
+	Projects []ProjectDefinition `json:"projects"`
+}
+
+type DeleteProjectResponse struct {
+	Id string `json:"id"`
+}
@@ -0,0 +1,80 @@
+package cms
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestReadProjectFileWithFlags(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "project.yaml")
+	os.WriteFile(file, []byte(`id: customer-a
+displayName: Customer A
+language: en
+parent: base
+permissions:
+  owner: [user:system:alice]
+  viewer: [role:system.everyone]
+`), 0644)
+
+	project, err := readProjectFile(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	set := flag.NewFlagSet("test", 0)
+	set.String("language", "", "")
+	set.String("editor", "", "")
+	set.String("display-name", "", "")
+	set.Parse([]string{"--language", "no", "--editor", "group:system:editors, user:system:bob", "customer-b"})
+	applyProjectFlags(cli.NewContext(nil, set, nil), project)
+
+	if project.Id != "customer-b" || project.DisplayName != "Customer A" || project.Language != "no" || project.Parent != "base" {
+		t.Errorf("unexpected project %+v", project)
+	}
+	if strings.Join(project.Permissions.Editor, ",") != "group:system:editors,user:system:bob" || len(project.Permissions.Owner) != 1 {
+		t.Errorf("unexpected permissions %+v", project.Permissions)
+	}
+	if err = validateProject(project); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	os.WriteFile(file, []byte("id: x\nowner: [user:system:alice]\n"), 0644)
+	if _, err = readProjectFile(file); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestValidateProject(t *testing.T) {
+	for expected, project := range map[string]ProjectDefinition{
+		"Not a valid project id":  {Id: "Customer"},
+		"its own parent":          {Id: "a", Parent: "a"},
+		"Not a valid language":    {Id: "a", Language: "english!"},
+		"Not a valid read access": {Id: "a", ReadAccess: "everyone"},
+		"Not a valid viewer":      {Id: "a", Permissions: ProjectPermissions{Viewer: []string{"everyone"}}},
+	} {
+		if err := validateProject(&project); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error containing %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestPrintProject(t *testing.T) {
+	var out bytes.Buffer
+	printProject(&out, &ProjectDefinition{
+		Id:          "customer-a",
+		DisplayName: "Customer A",
+		Language:    "en",
+		Permissions: ProjectPermissions{Owner: []string{"user:system:alice"}, Viewer: []string{"role:system.everyone"}},
+	})
+
+	want := "Id:             customer-a\nDisplay name:   Customer A\nLanguage:       en\n\nROLE     PRINCIPAL\nowner    user:system:alice\nviewer   role:system.everyone\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
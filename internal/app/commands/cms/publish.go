@@ -0,0 +1,147 @@
+package cms
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const PROJECT_PATH_FORMAT = "<project>:<content-path>"
+
+var PUBLISH_FLAGS = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "include-children",
+		Usage: "Include all content below the path",
+	},
+	cli.StringFlag{
+		Name:  "exclude",
+		Usage: "A comma-separated list of content paths to leave out, with the content below them",
+	},
+}
+
+var Publish = cli.Command{
+	Name:      "publish",
+	Usage:     "Publish content from the draft to the master branch of a project.",
+	ArgsUsage: PROJECT_PATH_FORMAT,
+	Flags:     append(PUBLISH_FLAGS, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		params := ensurePublishParams(c)
+
+		var result PublishResponse
+		status := common.RunTask(c, createProjectRequest(c, "content/publishTask", params), "Publishing", &result)
+
+		switch status.State {
+		case common.TASK_FINISHED:
+			fmt.Fprintf(os.Stderr, "Published %d content(s), deleted %d content(s) with %d failure(s)\n", len(result.Published), len(result.Deleted), len(result.Failed))
+		case common.TASK_FAILED:
+			fmt.Fprintf(os.Stderr, "Failed to publish: %s\n", status.Progress.Info)
+		}
+		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+
+		if status.State != common.TASK_FINISHED || len(result.Failed) > 0 {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var Unpublish = cli.Command{
+	Name:      "unpublish",
+	Usage:     "Remove content from the master branch of a project, keeping it in draft.",
+	ArgsUsage: PROJECT_PATH_FORMAT,
+	Flags:     append(append([]cli.Flag{common.FORCE_FLAG}, PUBLISH_FLAGS...), common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		params := ensurePublishParams(c)
+		if !common.IsForceMode(c) && !util.PromptBool(fmt.Sprintf("Unpublish '%s'%s", c.Args().First(), childrenNote(c)), false) {
+			os.Exit(1)
+		}
+
+		var result UnpublishResponse
+		status := common.RunTask(c, createProjectRequest(c, "content/unpublishTask", params), "Unpublishing", &result)
+
+		switch status.State {
+		case common.TASK_FINISHED:
+			fmt.Fprintf(os.Stderr, "Unpublished %d content(s) with %d failure(s)\n", len(result.Unpublished), len(result.Failed))
+		case common.TASK_FAILED:
+			fmt.Fprintf(os.Stderr, "Failed to unpublish: %s\n", status.Progress.Info)
+		}
+		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+
+		if status.State != common.TASK_FINISHED || len(result.Failed) > 0 {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func childrenNote(c *cli.Context) string {
+	if c.Bool("include-children") {
+		return " with all content below it"
+	}
+	return ""
+}
+
+func ensurePublishParams(c *cli.Context) map[string]interface{} {
+	projectPath, err := parseProjectPath(c.Args().First())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	excludes, err := parseExcludePaths(c.String("exclude"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	return map[string]interface{}{
+		"project":         projectPath.Project,
+		"contentPath":     projectPath.Path,
+		"includeChildren": c.Bool("include-children"),
+		"excludePaths":    excludes,
+	}
+}
+
+// parseProjectPath parses <project>:<content-path>, like customer-a:/site/news
+func parseProjectPath(value string) (*ProjectPath, error) {
+	project, path, found := strings.Cut(strings.TrimSpace(value), ":")
+	if !found || project == "" || !strings.HasPrefix(path, "/") {
+		return nil, errors.Errorf("'%s' must have the following format %s, e.g. 'default:/site'", value, PROJECT_PATH_FORMAT)
+	}
+	if err := validateProjectId(project); err != nil {
+		return nil, errors.New(strings.TrimSuffix(err.Error(), ": "))
+	}
+	return &ProjectPath{Project: project, Path: path}, nil
+}
+
+func parseExcludePaths(value string) ([]string, error) {
+	paths := common.SplitList(value)
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/") {
+			return nil, errors.Errorf("Excluded content path '%s' must start with /", path)
+		}
+	}
+	return paths, nil
+}
+
+type ProjectPath struct {
+	Project string
+	Path    string
+}
+
+type PublishResponse struct {
+	Published []string `json:"published"`
+	Deleted   []string `json:"deleted"`
+	Failed    []string `json:"failed"`
+}
+
+type UnpublishResponse struct {
+	Unpublished []string `json:"unpublished"`
+	Failed      []string `json:"failed"`
+}
@@ -0,0 +1,50 @@
+package cms
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestParseProjectPath(t *testing.T) {
+	path, err := parseProjectPath("customer-a:/site/news")
+	if err != nil || path.Project != "customer-a" || path.Path != "/site/news" {
+		t.Errorf("unexpected path %+v %v", path, err)
+	}
+
+	for _, invalid := range []string{"", "customer-a", "customer-a:site", ":/site", "Customer:/site"} {
+		if _, err = parseProjectPath(invalid); err == nil {
+			t.Errorf("expected '%s' to be invalid", invalid)
+		}
+	}
+}
+
+func TestParseExcludePaths(t *testing.T) {
+	paths, err := parseExcludePaths("/site/drafts, /site/old,")
+	if err != nil || strings.Join(paths, " ") != "/site/drafts /site/old" {
+		t.Errorf("unexpected paths %v %v", paths, err)
+	}
+	if _, err = parseExcludePaths("/site,drafts"); err == nil {
+		t.Error("expected an error for a relative path")
+	}
+}
+
+func TestOnlyUnpublishHasForceFlag(t *testing.T) {
+	hasForce := func(flags []cli.Flag) bool {
+		for _, flag := range flags {
+			if flag.GetName() == common.FORCE_FLAG.GetName() {
+				return true
+			}
+		}
+		return false
+	}
+
+	if hasForce(Publish.Flags) {
+		t.Error("publish does not ask for confirmation and should not take the force flag")
+	}
+	if !hasForce(Unpublish.Flags) {
+		t.Error("unpublish should take the force flag to skip its confirmation")
+	}
+}
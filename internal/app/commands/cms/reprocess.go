@@ -8,12 +8,15 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/urfave/cli"
 )
 
+var contentTypePattern = regexp.MustCompile(`^[a-zA-Z0-9._\-]+:[a-zA-Z0-9._\-]+$`)
+
 var Reprocess = cli.Command{
 	Name:  "reprocess",
 	Usage: "Reprocesses content in the repository.",
@@ -26,64 +29,113 @@ var Reprocess = cli.Command{
 			Name:  "skip-children",
 			Usage: "Flag to skip processing of content children.",
 		},
+		cli.StringFlag{
+			Name:  "project",
+			Usage: "A comma-separated list of projects to reprocess one after another, defaults to the default project",
+		},
+		cli.StringFlag{
+			Name:  "content-type",
+			Usage: "A comma-separated list of content types to reprocess, e.g. 'media:image,media:document'",
+		},
+		cli.StringFlag{
+			Name:  "query",
+			Usage: "NoQL query the reprocessed content has to match, e.g. \"modifiedTime < instant('2024-01-01T00:00:00Z')\"",
+		},
+		cli.StringFlag{
+			Name:  "report",
+			Usage: "File to write the reprocessed and failed content paths of each project to as JSON",
+		},
 		common.FORCE_FLAG,
 	}, common.AUTH_AND_TLS_FLAGS...),
 	Action: func(c *cli.Context) error {
 
-		var result ReprocessResponse
-		requestLabel := "Reprocessing"
-
 		ensurePathFlag(c)
+		projects := ensureProjectsFlag(c)
+		filtered := ensureFilterFlags(c)
 
-		req := createReprocessRequest(c, "content/reprocessTask")
-		res, err := common.SendRequestCustom(c, req, "", 3)
-		util.Fatal(err, "Reprocess request error")
+		results := make([]ReprocessResponse, 0, len(projects))
+		for _, project := range projects {
+			result := reprocess(c, project, filtered)
+			results = append(results, *result)
+		}
 
-		var taskResult common.TaskResponse
-		enonicErr, err := common.ParseResponseCustom(res, &taskResult)
+		if len(results) == 1 {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(results[0]))
+		} else {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(results))
+		}
+		if report := c.String("report"); report != "" {
+			util.Fatal(os.WriteFile(report, []byte(util.PrettyPrintJSON(reprocessReport(results))), 0644), "Could not write reprocess report:")
+			fmt.Fprintf(os.Stderr, "Saved reprocess report to '%s'\n", report)
+		}
+		if reprocessFailed(results) {
+			os.Exit(1)
+		}
 
-		if enonicErr != nil {
-			if enonicErr.Context.Authenticated {
-				if user, pass, ok := res.Request.BasicAuth(); ok {
-					// save the auth for future requests if any
-					c.Set("auth", fmt.Sprintf("%s:%s", user, pass))
-				}
-			}
+		return nil
+	},
+}
 
-			if enonicErr.Status == http.StatusNotFound {
-				// Async endpoint was not found, most likely XP version < 7.2 so trying synchronous endpoint
-				newReq := createReprocessRequest(c, "content/reprocess")
-				newRes := common.SendRequest(c, newReq, requestLabel)
-				common.ParseResponse(newRes, &result)
+// reprocess runs the reprocessing of one project, or of the default one when project is empty, showing
+// the progress of the task. XP before 7.2 has no task for it and only the whole path can be reprocessed there.
+func reprocess(c *cli.Context, project string, filtered bool) *ReprocessResponse {
+	result := &ReprocessResponse{}
+	requestLabel := "Reprocessing"
+	if project != "" {
+		requestLabel = fmt.Sprintf("Reprocessing %s", project)
+	}
 
-				fmt.Fprintf(os.Stderr, "Updated %d content(s) with %d error(s)\n", len(result.UpdatedContent), len(result.Errors))
-			} else {
-				fmt.Fprintf(os.Stderr, "%d %s\n", enonicErr.Status, enonicErr.Message)
-				os.Exit(1)
+	req := createReprocessRequest(c, "content/reprocessTask", project)
+	res, err := common.SendRequestCustom(c, req, "", 3)
+	util.Fatal(err, "Reprocess request error")
+
+	var taskResult common.TaskResponse
+	enonicErr, err := common.ParseResponseCustom(res, &taskResult)
+
+	if enonicErr != nil {
+		if enonicErr.Context.Authenticated {
+			if user, pass, ok := res.Request.BasicAuth(); ok {
+				// save the auth for future requests if any
+				c.Set("auth", fmt.Sprintf("%s:%s", user, pass))
 			}
+		}
 
-		} else if err != nil {
-			fmt.Fprintln(os.Stderr, err.Error())
-			os.Exit(1)
+		if enonicErr.Status == http.StatusNotFound && !filtered {
+			// Async endpoint was not found, most likely XP version < 7.2 so trying synchronous endpoint
+			newReq := createReprocessRequest(c, "content/reprocess", project)
+			newRes := common.SendRequest(c, newReq, requestLabel)
+			common.ParseResponse(newRes, result)
 
+			fmt.Fprintf(os.Stderr, "Updated %d content(s) with %d error(s)\n", len(result.UpdatedContent), len(result.Errors))
+		} else if enonicErr.Status == http.StatusNotFound {
+			fmt.Fprintln(os.Stderr, "Reprocessing by project, content type or query needs XP 7.2 or later")
+			os.Exit(1)
 		} else {
-			status := common.DisplayTaskProgress(c, taskResult.TaskId, requestLabel, &result)
+			fmt.Fprintf(os.Stderr, "%d %s\n", enonicErr.Status, enonicErr.Message)
+			os.Exit(1)
+		}
 
-			switch status.State {
-			case common.TASK_FINISHED:
-				fmt.Fprintf(os.Stderr, "Updated %d content(s) with %d error(s)\n", len(result.UpdatedContent), len(result.Errors))
-			case common.TASK_FAILED:
-				fmt.Fprintf(os.Stderr, "Failed to reprocess: %s\n", status.Progress.Info)
-			}
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+
+	} else {
+		status := common.DisplayTaskProgress(c, taskResult.TaskId, requestLabel, result)
 
+		switch status.State {
+		case common.TASK_FINISHED:
+			fmt.Fprintf(os.Stderr, "Updated %d content(s) with %d error(s)\n", len(result.UpdatedContent), len(result.Errors))
+		case common.TASK_FAILED:
+			fmt.Fprintf(os.Stderr, "Failed to reprocess: %s\n", status.Progress.Info)
+			result.TaskError = status.Progress.Info
 		}
-		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
 
-		return nil
-	},
+	}
+	result.Project = project
+	return result
 }
 
-func createReprocessRequest(c *cli.Context, url string) *http.Request {
+func createReprocessRequest(c *cli.Context, url, project string) *http.Request {
 	body := new(bytes.Buffer)
 	params := map[string]interface{}{
 		"sourceBranchPath": c.String("path"),
@@ -92,11 +144,77 @@ func createReprocessRequest(c *cli.Context, url string) *http.Request {
 	if skipChildren := c.Bool("skip-children"); skipChildren {
 		params["skipChildren"] = skipChildren
 	}
+	if project != "" {
+		params["project"] = project
+	}
+	if contentTypes := common.SplitList(c.String("content-type")); len(contentTypes) > 0 {
+		params["contentTypes"] = contentTypes
+	}
+	if query := strings.TrimSpace(c.String("query")); query != "" {
+		params["query"] = query
+	}
 	json.NewEncoder(body).Encode(params)
 
 	return common.CreateRequest(c, "POST", url, body)
 }
 
+// ensureProjectsFlag returns the projects to reprocess one after another, an empty project stands for the default one
+func ensureProjectsFlag(c *cli.Context) []string {
+	projects := common.SplitList(c.String("project"))
+	for _, project := range projects {
+		if err := validateProjectId(project); err != nil {
+			fmt.Fprintln(os.Stderr, strings.TrimSuffix(err.Error(), ": "))
+			os.Exit(1)
+		}
+	}
+	if len(projects) == 0 {
+		return []string{""}
+	}
+	return projects
+}
+
+// ensureFilterFlags validates the content types and tells if any filter is set
+func ensureFilterFlags(c *cli.Context) bool {
+	contentTypes := common.SplitList(c.String("content-type"))
+	for _, contentType := range contentTypes {
+		if !contentTypePattern.MatchString(contentType) {
+			fmt.Fprintf(os.Stderr, "Not a valid content type '%s', use <application>:<name> e.g. 'media:image'\n", contentType)
+			os.Exit(1)
+		}
+	}
+	return len(contentTypes) > 0 || strings.TrimSpace(c.String("query")) != "" || c.String("project") != ""
+}
+
+// reprocessReport lists the reprocessed and failed content of each project
+func reprocessReport(results []ReprocessResponse) []ReprocessReport {
+	report := make([]ReprocessReport, len(results))
+	for i, result := range results {
+		report[i] = ReprocessReport{
+			Project:     result.Project,
+			Error:       result.TaskError,
+			Reprocessed: result.UpdatedContent,
+			Failed:      result.Errors,
+		}
+		if report[i].Reprocessed == nil {
+			report[i].Reprocessed = []string{}
+		}
+		if report[i].Failed == nil {
+			report[i].Failed = []string{}
+		}
+	}
+	return report
+}
+
+// reprocessFailed tells if the task of any project failed or any content could not be reprocessed
+func reprocessFailed(results []ReprocessResponse) bool {
+	for _, result := range results {
+		if result.TaskError != "" || len(result.Errors) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func ensurePathFlag(c *cli.Context) {
 	force := common.IsForceMode(c)
 	pathValidator := func(val interface{}) error {
@@ -127,6 +245,16 @@ func ensurePathFlag(c *cli.Context) {
 }
 
 type ReprocessResponse struct {
+	Project string `json:"project,omitempty"`
+	// set when the task of the project failed
+	TaskError      string   `json:"taskError,omitempty"`
 	Errors         []string `json:"errors"`
 	UpdatedContent []string `json:"updatedContent"`
 }
+
+type ReprocessReport struct {
+	Project     string   `json:"project,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	Reprocessed []string `json:"reprocessed"`
+	Failed      []string `json:"failed"`
+}
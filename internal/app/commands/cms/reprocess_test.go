@@ -0,0 +1,48 @@
+package cms
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestReprocessReport(t *testing.T) {
+	report := reprocessReport([]ReprocessResponse{
+		{Project: "customer-a", UpdatedContent: []string{"/images/logo.png"}, Errors: []string{"/images/broken.png"}},
+		{Project: "customer-b"},
+		{Project: "customer-c", TaskError: "index error"},
+	})
+
+	data, _ := json.Marshal(report)
+	want := `[{"project":"customer-a","reprocessed":["/images/logo.png"],"failed":["/images/broken.png"]},{"project":"customer-b","reprocessed":[],"failed":[]},{"project":"customer-c","error":"index error","reprocessed":[],"failed":[]}]`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestReprocessFailed(t *testing.T) {
+	clean := ReprocessResponse{Project: "customer-a", UpdatedContent: []string{"/site"}}
+	if reprocessFailed([]ReprocessResponse{clean}) {
+		t.Error("expected no failure")
+	}
+	if !reprocessFailed([]ReprocessResponse{clean, {Project: "customer-b", TaskError: "index error"}}) {
+		t.Error("expected a failed task to fail the command")
+	}
+	if !reprocessFailed([]ReprocessResponse{clean, {Project: "customer-b", Errors: []string{"/broken"}}}) {
+		t.Error("expected content errors to fail the command")
+	}
+}
+
+func TestContentTypePattern(t *testing.T) {
+	for contentType, valid := range map[string]bool{
+		"media:image":             true,
+		"com.example.app:article": true,
+		"portal:site":             true,
+		"image":                   false,
+		"media:image:extra":       false,
+		"media: image":            false,
+	} {
+		if contentTypePattern.MatchString(contentType) != valid {
+			t.Errorf("expected '%s' valid to be %v", contentType, valid)
+		}
+	}
+}
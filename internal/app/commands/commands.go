@@ -7,6 +7,7 @@ import (
 	"cli-enonic/internal/app/commands/cms"
 	"cli-enonic/internal/app/commands/dump"
 	"cli-enonic/internal/app/commands/export"
+	"cli-enonic/internal/app/commands/node"
 	"cli-enonic/internal/app/commands/project"
 	"cli-enonic/internal/app/commands/repo"
 	"cli-enonic/internal/app/commands/sandbox"
@@ -46,6 +47,12 @@ func All() []cli.Command {
 			HelpName:    "Repo",
 			Subcommands: repo.All(),
 		},
+		{
+			Name:        "node",
+			Usage:       "Browse nodes of repositories",
+			HelpName:    "Node",
+			Subcommands: node.All(),
+		},
 		{
 			Name:        "cms",
 			Usage:       "CMS commands",
@@ -26,6 +26,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -49,6 +50,9 @@ const RESTART_ALL_RUNNING_INSTANCES_MSG = "Please restart all running instances
 
 var spin *spinner.Spinner
 
+// runtimeDataMutex keeps requests sent from several goroutines from reading and writing the runtime data file at once
+var runtimeDataMutex sync.Mutex
+
 func init() {
 	spin = spinner.New(spinner.CharSets[26], 300*time.Millisecond)
 	spin.Writer = os.Stderr
@@ -74,6 +78,11 @@ var COMPAT_FLAG = cli.StringFlag{
 	Usage: "XP version compatibility mode. Set to \"7\" (or any value starting with \"7\") to use the legacy XP 7 API format. Default uses the XP 8 API format.",
 }
 
+var SANDBOX_FLAG = cli.StringFlag{
+	Name:  "sandbox, s",
+	Usage: "Local sandbox to transfer the data from or to, its home folder is used directly instead of the remote",
+}
+
 var CLIENT_KEY_FLAG = cli.StringFlag{
 	Name:  "client-key",
 	Usage: "Specifies the private key file for client certificate authentication. This option is used in conjunction with --client-cert to establish a mutual TLS (mTLS) session.",
@@ -95,6 +104,16 @@ func IsForceMode(c *cli.Context) bool {
 	return c != nil && c.Bool("force")
 }
 
+// EnsureOverwrite asks before an existing output is replaced and exits if it should not be
+func EnsureOverwrite(c *cli.Context, output string) {
+	if _, err := os.Stat(output); err != nil {
+		return
+	}
+	if !IsForceMode(c) && !util.PromptBool(fmt.Sprintf("'%s' already exists. Overwrite", output), false) {
+		os.Exit(1)
+	}
+}
+
 func IsCompatMode(c *cli.Context) bool {
 	return c != nil && strings.HasPrefix(c.String("compat"), "7")
 }
@@ -199,6 +218,9 @@ func WriteProjectData(data *ProjectData, prjPath string) {
 }
 
 func ReadRuntimeData() RuntimeData {
+	runtimeDataMutex.Lock()
+	defer runtimeDataMutex.Unlock()
+
 	enonicPath := GetInEnonicDir(".enonic")
 	file := util.OpenOrCreateDataFile(enonicPath, true)
 	defer file.Close()
@@ -262,6 +284,9 @@ func isDockerContainerRunning(containerName string) bool {
 }
 
 func WriteRuntimeData(data RuntimeData) {
+	runtimeDataMutex.Lock()
+	defer runtimeDataMutex.Unlock()
+
 	enonicPath := GetInEnonicDir(".enonic")
 	file := util.OpenOrCreateDataFile(enonicPath, false)
 	defer file.Close()
@@ -294,6 +319,20 @@ func EnsureAuth(authString string, force bool) (string, string) {
 	return splitAuth[0], splitAuth[1]
 }
 
+// EnsureAuthFlag resolves the credentials once and keeps them in the auth flag, so that requests sent from
+// several goroutines neither prompt for them nor depend on the session stored in the runtime data
+func EnsureAuthFlag(c *cli.Context) {
+	if c.String("auth") != "" || resolveCredFilePath(c.String("cred-file")) != "" {
+		return
+	}
+	auth := ""
+	if activeRemote := remote.GetActiveRemote(); activeRemote.User != "" || activeRemote.Pass != "" {
+		auth = fmt.Sprintf("%s:%s", activeRemote.User, activeRemote.Pass)
+	}
+	user, pass := EnsureAuth(auth, IsForceMode(c))
+	c.Set("auth", fmt.Sprintf("%s:%s", user, pass))
+}
+
 func getValueOrDefault(path string, defaultValue string) string {
 	if path == "" {
 		path = defaultValue
@@ -312,7 +351,7 @@ func CreateRequest(c *cli.Context, method, url string, body io.Reader) *http.Req
 		credFilePath = resolveCredFilePath(c.String("cred-file"))
 	}
 
-	if url != MARKET_URL && url != SCOOP_MANIFEST_URL && (ReadRuntimeData().SessionID == "" || auth != "" || credFilePath != "") {
+	if url != MARKET_URL && url != SCOOP_MANIFEST_URL && (ReadRuntimeData().SessionID == "" || auth != "" || credFilePath != "" || remote.IsRemoteOverridden()) {
 		if credFilePath != "" {
 			jwtToken := generateServiceAccountJwtToken(credFilePath)
 			return doCreateRequestBearerAuthRequest(method, url, jwtToken, body)
@@ -382,7 +421,7 @@ func doCreateBasicAuthRequest(method, reqUrl, user, pass string, body io.Reader,
 	if user != "" {
 		req.SetBasicAuth(user, pass)
 
-		if rData.SessionID != "" {
+		if rData.SessionID != "" && !remote.IsRemoteOverridden() {
 			rData.SessionID = ""
 			WriteRuntimeData(rData)
 		}
@@ -410,6 +449,8 @@ func SendRequestCustom(c *cli.Context, req *http.Request, message string, timeou
 	if c != nil {
 		isCredFileAbsent = resolveCredFilePath(c.String("cred-file")) == ""
 	}
+	// don't let a session of an explicitly selected remote replace the one of the env defined remote
+	storeSession := isCredFileAbsent && !remote.IsRemoteOverridden()
 
 	tlsKey := getValueOrDefault(c.String(CLIENT_KEY_FLAG.Name), os.Getenv("ENONIC_CLI_CLIENT_KEY"))
 	tlsCert := getValueOrDefault(c.String(CLIENT_CERT_FLAG.Name), os.Getenv("ENONIC_CLI_CLIENT_CERT"))
@@ -471,7 +512,7 @@ func SendRequestCustom(c *cli.Context, req *http.Request, message string, timeou
 
 	rData := ReadRuntimeData()
 	if res.StatusCode >= 200 && res.StatusCode < 300 {
-		if isCredFileAbsent {
+		if storeSession {
 			for _, cookie := range res.Cookies() {
 				if cookie.Name == JSESSIONID && cookie.Value != rData.SessionID {
 					rData.SessionID = cookie.Value
@@ -481,7 +522,7 @@ func SendRequestCustom(c *cli.Context, req *http.Request, message string, timeou
 		}
 	} else if res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusUnauthorized {
 		if isCredFileAbsent {
-			if rData.SessionID != "" {
+			if rData.SessionID != "" && storeSession {
 				fmt.Fprint(os.Stderr, "User session is not valid.")
 				rData.SessionID = ""
 				WriteRuntimeData(rData)
@@ -517,6 +558,10 @@ func SendRequestCustom(c *cli.Context, req *http.Request, message string, timeou
 
 			user, pass = EnsureAuth(auth, forceBool)
 			fmt.Fprintln(os.Stderr, "")
+			if c != nil && c.String("auth") != "" {
+				// the retry is created from the auth flag, so it has to carry the corrected credentials
+				c.Set("auth", fmt.Sprintf("%s:%s", user, pass))
+			}
 
 			newReq := CreateRequest(c, req.Method, req.URL.String(), bodyCopy)
 			// need to set it for install requests, because their content type may vary
@@ -0,0 +1,31 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SplitList splits a comma separated flag value, leaving out empty items
+func SplitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// PrintPage tells which items of the total are shown and how to get the next page
+func PrintPage(out io.Writer, items string, start, shown int, total int64) {
+	if shown == 0 {
+		fmt.Fprintf(out, "No %s found from %d, total %d\n", items, start, total)
+		return
+	}
+	fmt.Fprintf(out, "Showing %d-%d of %d", start+1, start+shown, total)
+	if next := int64(start + shown); next < total {
+		fmt.Fprintf(out, ", use --start %d for the next page", next)
+	}
+	fmt.Fprintln(out)
+}
@@ -0,0 +1,34 @@
+package common
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSplitList(t *testing.T) {
+	if got := SplitList(" system.*, ,other,"); !reflect.DeepEqual(got, []string{"system.*", "other"}) {
+		t.Errorf("got %q", got)
+	}
+	if got := SplitList(""); got == nil || len(got) != 0 {
+		t.Errorf("expected an empty list, got %#v", got)
+	}
+}
+
+func TestPrintPage(t *testing.T) {
+	for _, test := range []struct {
+		start, shown int
+		total        int64
+		want         string
+	}{
+		{0, 20, 45, "Showing 1-20 of 45, use --start 20 for the next page\n"},
+		{40, 5, 45, "Showing 41-45 of 45\n"},
+		{50, 0, 45, "No nodes found from 50, total 45\n"},
+	} {
+		var out bytes.Buffer
+		PrintPage(&out, "nodes", test.start, test.shown, test.total)
+		if out.String() != test.want {
+			t.Errorf("got %q, want %q", out.String(), test.want)
+		}
+	}
+}
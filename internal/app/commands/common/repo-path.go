@@ -0,0 +1,49 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const REPO_PATH_FORMAT = "<repo-name>:<branch-name>:<node-path>"
+const REPO_BRANCH_FORMAT = "<repo-name>:<branch-name>"
+
+// RepoPath is a location in a repository given as <repo-name>:<branch-name>:<node-path>,
+// where the node part can also be a node id
+type RepoPath struct {
+	Repo   string
+	Branch string
+	Node   string
+}
+
+// ParseRepoPath parses <repo-name>:<branch-name>:<node-path>, or <repo-name>:<branch-name> when withNode is false
+func ParseRepoPath(value string, withNode bool) (*RepoPath, error) {
+	format, parts := REPO_BRANCH_FORMAT, 2
+	if withNode {
+		format, parts = REPO_PATH_FORMAT, 3
+	}
+
+	split := strings.Split(strings.TrimSpace(value), ":")
+	if len(split) != parts {
+		return nil, errors.Errorf("'%s' must have the following format %s", value, format)
+	}
+	for _, part := range split {
+		if strings.TrimSpace(part) == "" {
+			return nil, errors.Errorf("'%s' must have the following format %s", value, format)
+		}
+	}
+
+	path := &RepoPath{Repo: split[0], Branch: split[1]}
+	if withNode {
+		path.Node = split[2]
+	}
+	return path, nil
+}
+
+func (p *RepoPath) String() string {
+	if p.Node == "" {
+		return p.Repo + ":" + p.Branch
+	}
+	return p.Repo + ":" + p.Branch + ":" + p.Node
+}
@@ -0,0 +1,34 @@
+package common
+
+import "testing"
+
+func TestParseRepoPath(t *testing.T) {
+	path, err := ParseRepoPath("com.enonic.cms.default:draft:/content/site", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path.Repo != "com.enonic.cms.default" || path.Branch != "draft" || path.Node != "/content/site" {
+		t.Errorf("unexpected path %+v", path)
+	}
+	if path.String() != "com.enonic.cms.default:draft:/content/site" {
+		t.Errorf("got %s", path.String())
+	}
+
+	path, err = ParseRepoPath("system-repo:master:1f7a7c6b-7d36-4d6e-94d0-8b1f1c1b8e11", true)
+	if err != nil || path.Node != "1f7a7c6b-7d36-4d6e-94d0-8b1f1c1b8e11" {
+		t.Errorf("expected a node id, got %+v %v", path, err)
+	}
+
+	if path, err = ParseRepoPath("system-repo:master", false); err != nil || path.String() != "system-repo:master" {
+		t.Errorf("unexpected branch path %+v %v", path, err)
+	}
+
+	for _, invalid := range []string{"", "repo:draft", "repo::/", "repo:draft:/:x"} {
+		if _, err = ParseRepoPath(invalid, true); err == nil {
+			t.Errorf("expected '%s' to be invalid", invalid)
+		}
+	}
+	if _, err = ParseRepoPath("repo:draft:/", false); err == nil {
+		t.Error("expected a node path to be invalid without node")
+	}
+}
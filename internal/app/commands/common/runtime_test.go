@@ -0,0 +1,34 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRuntimeDataConcurrentAccess(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("ENONIC_CLI_HOME_PATH", home)
+	os.MkdirAll(filepath.Join(home, ".enonic"), 0755)
+	WriteRuntimeData(RuntimeData{Running: "box"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data := ReadRuntimeData()
+			data.SessionID = fmt.Sprintf("session-%d", i)
+			WriteRuntimeData(data)
+		}(i)
+	}
+	wg.Wait()
+
+	data := ReadRuntimeData()
+	if data.Running != "box" || !strings.HasPrefix(data.SessionID, "session-") {
+		t.Errorf("runtime data was corrupted: %+v", data)
+	}
+}
@@ -97,7 +97,16 @@ func doDisplayTaskSpinner(c *cli.Context, taskId, msg string, doneCh chan<- *Tas
 	}
 }
 
-func doDisplayTaskProgress(c *cli.Context, taskId, msg string, doneCh chan<- *TaskStatus) {
+// DisplayTaskProgressBar waits for the task showing its progress in the bar, so that the bars
+// of several tasks can be shown at once in a pb.Pool
+func DisplayTaskProgressBar(c *cli.Context, taskId string, bar *pb.ProgressBar, target interface{}) *TaskStatus {
+	return waitForTask(c, taskId, "", target, func(c *cli.Context, taskId, msg string, doneCh chan<- *TaskStatus) {
+		trackTaskProgress(c, taskId, bar, doneCh)
+	})
+}
+
+// NewTaskProgressBar creates a percentage bar for a task, it is started by the caller
+func NewTaskProgressBar(msg string) *pb.ProgressBar {
 	bar := pb.New(100)
 	bar.ShowSpeed = false
 	bar.ShowCounters = false
@@ -105,7 +114,17 @@ func doDisplayTaskProgress(c *cli.Context, taskId, msg string, doneCh chan<- *Ta
 	bar.ShowTimeLeft = false
 	bar.ShowElapsedTime = false
 	bar.ShowFinalTime = false
-	bar.Prefix(msg + " ").SetRefreshRate(time.Second).Start()
+	bar.Prefix(msg + " ").SetRefreshRate(time.Second)
+	return bar
+}
+
+func doDisplayTaskProgress(c *cli.Context, taskId, msg string, doneCh chan<- *TaskStatus) {
+	bar := NewTaskProgressBar(msg)
+	bar.Start()
+	trackTaskProgress(c, taskId, bar, doneCh)
+}
+
+func trackTaskProgress(c *cli.Context, taskId string, bar *pb.ProgressBar, doneCh chan<- *TaskStatus) {
 	var exitFlag bool
 	for {
 		time.Sleep(time.Second)
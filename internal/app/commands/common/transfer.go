@@ -0,0 +1,523 @@
+package common
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"gopkg.in/cheggaaa/pb.v1"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const UPLOAD_CHUNK_SIZE = 8 * 1024 * 1024
+const DIGEST_HEADER = "Digest"
+const DIGEST_SHA256 = "sha-256="
+const PART_FILE_EXT = ".part"
+const PART_INFO_EXT = ".json"
+
+// DownloadArchive fetches the archive served at the url into the target file, continuing a previous partial download
+// if there is one. Returns the checksum announced by the server or an empty string if it did not send any.
+func DownloadArchive(c *cli.Context, archiveUrl, target, label string) (string, error) {
+	partFile := target + PART_FILE_EXT
+	for {
+		checksum, restart, err := downloadPart(c, archiveUrl, partFile, label)
+		if restart {
+			// the partial file belongs to another archive or an older version of this one
+			fmt.Fprintln(os.Stderr, "Partial download does not match the archive, starting over")
+			RemovePartFile(partFile)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		os.Remove(partFile + PART_INFO_EXT)
+		return checksum, os.Rename(partFile, target)
+	}
+}
+
+// downloadPart fetches the archive into the partial file, resuming it only when the info saved next to it tells that
+// it holds the start of the same archive. Tells to restart when the server shows that the partial file does not fit.
+func downloadPart(c *cli.Context, archiveUrl, partFile, label string) (string, bool, error) {
+	var offset int64
+	info := ReadPartInfo(partFile)
+	if stat, err := os.Stat(partFile); err == nil {
+		if info == nil || info.Url != archiveUrl {
+			return "", true, nil
+		}
+		offset = stat.Size()
+	}
+
+	req := CreateRequest(c, "GET", archiveUrl, nil)
+	req.Header.Set("Accept", "application/zip")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if info.ETag != "" {
+			// the server sends the whole archive instead of the range if it changed since
+			req.Header.Set("If-Range", info.ETag)
+		}
+	}
+
+	res, err := SendRequestCustom(c, req, "", 60*24)
+	if err != nil {
+		return "", false, err
+	}
+	defer res.Body.Close()
+	checksum := ParseDigest(res.Header.Get(DIGEST_HEADER))
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		if total := contentRangeTotal(res.Header.Get("Content-Range")); total != info.Size || (info.Checksum != "" && checksum != "" && !strings.EqualFold(checksum, info.Checksum)) {
+			return "", true, nil
+		}
+		fmt.Fprintf(os.Stderr, "Resuming download at %d bytes\n", offset)
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// server ignored the range or the archive changed, start over
+		offset = 0
+		flags |= os.O_TRUNC
+		info = &PartInfo{Url: archiveUrl, Size: res.ContentLength, ETag: res.Header.Get("ETag"), Checksum: checksum}
+		if err = WritePartInfo(partFile, info); err != nil {
+			return "", false, err
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial file is only taken as complete when the server tells its checksum and it matches
+		if checksum == "" {
+			return "", true, nil
+		}
+		if actual, err := FileChecksum(partFile); err != nil || !strings.EqualFold(actual, checksum) {
+			return "", true, nil
+		}
+		return checksum, false, nil
+	default:
+		return "", false, responseError(res)
+	}
+
+	file, err := os.OpenFile(partFile, flags, 0640)
+	if err != nil {
+		return "", false, err
+	}
+
+	bar := NewTransferBar(label, offset+res.ContentLength, offset)
+	_, err = io.Copy(file, bar.NewProxyReader(res.Body))
+	bar.Finish()
+	file.Close()
+	if err != nil {
+		return "", false, errors.Wrap(err, "download interrupted, run the command again to resume")
+	}
+	return checksum, false, nil
+}
+
+// PartInfo is saved next to a partial download to tell which archive it is the start of
+type PartInfo struct {
+	Url      string `json:"url"`
+	Size     int64  `json:"size"`
+	ETag     string `json:"etag,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// ReadPartInfo returns the info saved next to the partial file or nil if there is none
+func ReadPartInfo(partFile string) *PartInfo {
+	data, err := os.ReadFile(partFile + PART_INFO_EXT)
+	if err != nil {
+		return nil
+	}
+	var info PartInfo
+	if json.Unmarshal(data, &info) != nil {
+		return nil
+	}
+	return &info
+}
+
+func WritePartInfo(partFile string, info *PartInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(partFile+PART_INFO_EXT, data, 0640)
+}
+
+// RemovePartFile removes the partial file with its info
+func RemovePartFile(partFile string) {
+	os.Remove(partFile)
+	os.Remove(partFile + PART_INFO_EXT)
+}
+
+// contentRangeTotal returns the full size from a "bytes <start>-<end>/<size>" header or -1 if it is unknown
+func contentRangeTotal(contentRange string) int64 {
+	var start, end, total int64
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &total); err != nil {
+		return -1
+	}
+	return total
+}
+
+// StreamArchive fetches the archive served at the url into the writer, for archives that must not be stored as they are.
+// Nothing is kept between runs, so an interrupted download starts over. Returns the checksum announced by the server,
+// or an empty string if it did not send any, and the checksum of the received archive.
+func StreamArchive(c *cli.Context, archiveUrl string, target io.Writer, label string) (string, string, error) {
+	req := CreateRequest(c, "GET", archiveUrl, nil)
+	req.Header.Set("Accept", "application/zip")
+
+	res, err := SendRequestCustom(c, req, "", 60*24)
+	if err != nil {
+		return "", "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", "", responseError(res)
+	}
+
+	hash := sha256.New()
+	bar := NewTransferBar(label, res.ContentLength, 0)
+	_, err = io.Copy(io.MultiWriter(target, hash), bar.NewProxyReader(res.Body))
+	bar.Finish()
+	if err != nil {
+		return "", "", errors.Wrap(err, "download interrupted")
+	}
+
+	return ParseDigest(res.Header.Get(DIGEST_HEADER)), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func responseError(res *http.Response) error {
+	var body interface{}
+	if enonicErr, _ := ParseResponseCustom(res, &body); enonicErr != nil {
+		return errors.New(enonicErr.Message)
+	}
+	return errors.New(res.Status)
+}
+
+// UploadArchive sends the archive in chunks to the upload url, asking the server first how much of it it already has,
+// and asks the server to verify it with the complete url once all chunks are sent
+func UploadArchive(c *cli.Context, uploadUrl, completeUrl, name, archive, label string) (*UploadResult, error) {
+	info, err := os.Stat(archive)
+	if err != nil {
+		return nil, err
+	}
+	return UploadStream(c, uploadUrl, completeUrl, name, label, info.Size(), func() (io.ReadCloser, error) {
+		return os.Open(archive)
+	})
+}
+
+// UploadStream works like UploadArchive for archives that are produced while they are read, like decrypted ones.
+// The size is only used for the progress and is 0 when unknown. The part the server already has is read again
+// to compute the checksum, and the stream is opened again from the start if the server has more than it holds.
+func UploadStream(c *cli.Context, uploadUrl, completeUrl, name, label string, size int64, open func() (io.ReadCloser, error)) (*UploadResult, error) {
+	offset := fetchUploadedSize(c, uploadUrl, name)
+	if size > 0 && offset > size {
+		offset = 0
+	}
+
+	var reader io.ReadCloser
+	hash := sha256.New()
+	for {
+		var err error
+		if reader, err = open(); err != nil {
+			return nil, err
+		}
+		hash.Reset()
+		if _, err = io.CopyN(hash, reader, offset); err == nil {
+			break
+		}
+		reader.Close()
+		if err != io.EOF {
+			return nil, err
+		}
+		// the uploaded part is longer than the archive, it belongs to another one
+		offset = 0
+	}
+	defer reader.Close()
+	if offset > 0 {
+		fmt.Fprintf(os.Stderr, "Resuming upload at %d bytes\n", offset)
+	}
+
+	in := io.TeeReader(reader, hash)
+	bar := NewTransferBar(label, size, offset)
+	chunk := make([]byte, UPLOAD_CHUNK_SIZE)
+	for {
+		read, readErr := io.ReadFull(in, chunk)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			bar.Finish()
+			return nil, readErr
+		}
+		if read > 0 {
+			if err := uploadChunk(c, uploadUrl, name, offset, chunk[:read]); err != nil {
+				bar.Finish()
+				return nil, errors.Wrap(err, "upload interrupted, run the command again to resume")
+			}
+			offset += int64(read)
+			bar.Add(read)
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	bar.Finish()
+
+	return completeUpload(c, completeUrl, name, offset, hex.EncodeToString(hash.Sum(nil)))
+}
+
+func fetchUploadedSize(c *cli.Context, uploadUrl, name string) int64 {
+	req := CreateRequest(c, "GET", uploadUrl+"?name="+url.QueryEscape(name), nil)
+	res, err := SendRequestCustom(c, req, "", 1)
+	if err != nil {
+		return 0
+	}
+
+	var result UploadResult
+	if enonicErr, err := ParseResponseCustom(res, &result); enonicErr != nil || err != nil {
+		// nothing uploaded yet
+		return 0
+	}
+	return result.Size
+}
+
+func uploadChunk(c *cli.Context, uploadUrl, name string, offset int64, data []byte) error {
+	req := CreateRequest(c, "POST", fmt.Sprintf("%s?name=%s&offset=%d", uploadUrl, url.QueryEscape(name), offset), bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	res, err := SendRequestCustom(c, req, "", 10)
+	if err != nil {
+		return err
+	}
+
+	var result UploadResult
+	if enonicErr, err := ParseResponseCustom(res, &result); enonicErr != nil {
+		return errors.New(enonicErr.Message)
+	} else if err != nil {
+		return err
+	}
+	if result.Size != offset+int64(len(data)) {
+		return errors.Errorf("server has %d bytes after chunk at %d, expected %d", result.Size, offset, offset+int64(len(data)))
+	}
+	return nil
+}
+
+func completeUpload(c *cli.Context, completeUrl, name string, size int64, checksum string) (*UploadResult, error) {
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(map[string]interface{}{
+		"name":   name,
+		"size":   size,
+		"sha256": checksum,
+	})
+	req := CreateRequest(c, "POST", completeUrl, body)
+
+	res, err := SendRequestCustom(c, req, "Verifying upload", 60)
+	if err != nil {
+		return nil, err
+	}
+
+	var result UploadResult
+	if enonicErr, err := ParseResponseCustom(res, &result); enonicErr != nil {
+		return nil, errors.New(enonicErr.Message)
+	} else if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(result.Sha256, checksum) {
+		return nil, errors.Errorf("checksum mismatch: local %s, server %s", checksum, result.Sha256)
+	}
+	return &result, nil
+}
+
+// NewTransferBar starts a progress bar in bytes, current is the amount transferred by a previous run
+func NewTransferBar(prefix string, total, current int64) *pb.ProgressBar {
+	bar := pb.New64(total)
+	bar.ShowSpeed = true
+	bar.ShowCounters = true
+	bar.ShowPercent = true
+	bar.ShowTimeLeft = true
+	bar.ShowElapsedTime = false
+	bar.ShowFinalTime = false
+	bar.Output = os.Stderr
+	bar.Set64(current)
+	bar.Prefix(prefix + " ").SetUnits(pb.U_BYTES).SetRefreshRate(200 * time.Millisecond).Start()
+	return bar
+}
+
+// ParseDigest reads a sha-256 instance digest (RFC 3230) and returns it hex encoded
+func ParseDigest(header string) string {
+	for _, digest := range strings.Split(header, ",") {
+		digest = strings.TrimSpace(digest)
+		if len(digest) > len(DIGEST_SHA256) && strings.EqualFold(digest[:len(DIGEST_SHA256)], DIGEST_SHA256) {
+			if decoded, err := base64.StdEncoding.DecodeString(digest[len(DIGEST_SHA256):]); err == nil {
+				return hex.EncodeToString(decoded)
+			}
+		}
+	}
+	return ""
+}
+
+func FileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// VerifyChecksum compares the file with the hex encoded sha-256 checksum, an empty checksum is not verified
+func VerifyChecksum(path, expected string) error {
+	if expected == "" {
+		fmt.Fprintln(os.Stderr, "No checksum available, skipping verification")
+		return nil
+	}
+	actual, err := FileChecksum(path)
+	if err != nil {
+		return err
+	}
+	return CompareChecksum(actual, expected)
+}
+
+// CompareChecksum tells if two hex encoded sha-256 checksums differ
+func CompareChecksum(actual, expected string) error {
+	if !strings.EqualFold(actual, expected) {
+		return errors.Errorf("checksum mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+// ArchiveDir zips the content of the folder, entries are relative to the folder itself
+func ArchiveDir(dir string, target io.Writer) error {
+	writer := zip.NewWriter(target)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		header.Method = zip.Deflate
+
+		entry, err := writer.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(entry, file)
+		return err
+	})
+	if err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// ExtractArchive unpacks a zip archive into the target folder.
+// Archives made by XP keep the content in a root folder named after it, that folder is skipped.
+func ExtractArchive(archive, target string) error {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	root := FindArchiveRoot(reader.File)
+	for _, f := range reader.File {
+		name := strings.TrimPrefix(f.Name, root)
+		if name == "" || f.FileInfo().IsDir() {
+			continue
+		}
+		destPath := filepath.Join(target, filepath.FromSlash(name))
+		if !strings.HasPrefix(destPath, filepath.Clean(target)+string(os.PathSeparator)) {
+			return errors.Errorf("illegal file path in archive: %s", f.Name)
+		}
+		if err = extractArchiveFile(f, destPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractArchiveFile(f *zip.File, destPath string) error {
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return err
+	}
+	reader, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	file, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, reader)
+	return err
+}
+
+// FindArchiveRoot returns the single root folder prefix (with trailing slash) shared by all entries, if any
+func FindArchiveRoot(files []*zip.File) string {
+	var root string
+	for _, f := range files {
+		first, rest, found := strings.Cut(f.Name, "/")
+		if !found || (rest == "" && !f.FileInfo().IsDir()) {
+			return ""
+		}
+		if root == "" {
+			root = first
+		} else if root != first {
+			return ""
+		}
+	}
+	if root == "" {
+		return ""
+	}
+	return root + "/"
+}
+
+func CopyFile(source, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+type UploadResult struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
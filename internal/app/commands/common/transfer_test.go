@@ -0,0 +1,227 @@
+package common
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	writer := zip.NewWriter(buf)
+	for name, content := range files {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("create entry: %v", err)
+		}
+		entry.Write([]byte(content))
+	}
+	writer.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("write zip: %v", err)
+	}
+}
+
+func TestExtractArchiveStripsRootFolder(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "mydump.zip")
+	writeZip(t, archive, map[string]string{
+		"mydump/dump.json":                  "{}",
+		"mydump/meta/system-repo/draft.zip": "meta",
+	})
+
+	target := filepath.Join(t.TempDir(), "mydump")
+	if err := ExtractArchive(archive, target); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(target, "dump.json")); string(content) != "{}" {
+		t.Errorf("unexpected dump.json content: %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(target, "meta", "system-repo", "draft.zip")); err != nil {
+		t.Errorf("expected nested file to be extracted: %v", err)
+	}
+}
+
+func TestExtractArchiveRejectsTraversal(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "evil.zip")
+	writeZip(t, archive, map[string]string{
+		"dump.json":     "{}",
+		"../escape.txt": "x",
+	})
+
+	if err := ExtractArchive(archive, filepath.Join(t.TempDir(), "evil")); err == nil {
+		t.Error("expected error for path outside of the target folder")
+	}
+}
+
+func TestArchiveDirRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mydump")
+	os.MkdirAll(filepath.Join(dir, "blob"), 0755)
+	os.WriteFile(filepath.Join(dir, "dump.json"), []byte("{}"), 0644)
+	os.WriteFile(filepath.Join(dir, "blob", "binary"), []byte("data"), 0644)
+
+	archive := filepath.Join(t.TempDir(), "mydump.zip")
+	file, _ := os.Create(archive)
+	if err := ArchiveDir(dir, file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	file.Close()
+
+	target := filepath.Join(t.TempDir(), "copy")
+	if err := ExtractArchive(archive, target); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(target, "blob", "binary")); string(content) != "data" {
+		t.Errorf("unexpected binary content: %q", content)
+	}
+}
+
+func TestFindArchiveRoot(t *testing.T) {
+	files := func(names ...string) []*zip.File {
+		result := make([]*zip.File, len(names))
+		for i, name := range names {
+			result[i] = &zip.File{FileHeader: zip.FileHeader{Name: name}}
+		}
+		return result
+	}
+
+	cases := []struct {
+		names []string
+		want  string
+	}{
+		{[]string{"dump/", "dump/dump.json", "dump/meta/x"}, "dump/"},
+		{[]string{"dump.json", "meta/x"}, ""},
+		{[]string{"a/dump.json", "b/x"}, ""},
+		{[]string{}, ""},
+	}
+	for _, tc := range cases {
+		if got := FindArchiveRoot(files(tc.names...)); got != tc.want {
+			t.Errorf("FindArchiveRoot(%v) = %q, want %q", tc.names, got, tc.want)
+		}
+	}
+}
+
+func TestParseDigest(t *testing.T) {
+	sum := sha256.Sum256([]byte("dump"))
+	encoded := base64.StdEncoding.EncodeToString(sum[:])
+
+	if got := ParseDigest("md5=abc, SHA-256=" + encoded); got != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected digest: %q", got)
+	}
+	if got := ParseDigest("md5=abc"); got != "" {
+		t.Errorf("expected no digest, got %q", got)
+	}
+}
+
+// archiveServer serves the content honouring Range and If-Range like a server with the given ETag,
+// the ranges asked for are collected in requests
+func archiveServer(t *testing.T, content []byte, etag string, requests *[]string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rangeHeader := r.Header.Get("Range")
+		*requests = append(*requests, rangeHeader+"|"+r.Header.Get("If-Range"))
+		w.Header().Set("ETag", etag)
+		var offset int
+		if rangeHeader != "" && (r.Header.Get("If-Range") == "" || r.Header.Get("If-Range") == etag) {
+			fmt.Sscanf(rangeHeader, "bytes=%d-", &offset)
+			if offset >= len(content) {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(content)-1, len(content)))
+			w.Header().Set("Content-Length", fmt.Sprint(len(content)-offset))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		w.Write(content[offset:])
+	}))
+	t.Cleanup(server.Close)
+
+	home := t.TempDir()
+	os.MkdirAll(filepath.Join(home, ".enonic"), 0755)
+	t.Setenv("ENONIC_CLI_HOME_PATH", home)
+	t.Setenv("ENONIC_CLI_REMOTE_URL", server.URL)
+	t.Setenv("ENONIC_CLI_REMOTE_USER", "su")
+	t.Setenv("ENONIC_CLI_REMOTE_PASS", "password")
+}
+
+func downloadTestArchive(t *testing.T, target string) {
+	t.Helper()
+	c := cli.NewContext(nil, flag.NewFlagSet("test", 0), nil)
+	if _, err := DownloadArchive(c, "archive", target, "Downloading"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDownloadArchiveResumesSameArchive(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+	var requests []string
+	archiveServer(t, content, `"v1"`, &requests)
+
+	target := filepath.Join(t.TempDir(), "archive.zip")
+	os.WriteFile(target+PART_FILE_EXT, content[:300], 0644)
+	WritePartInfo(target+PART_FILE_EXT, &PartInfo{Url: "archive", Size: int64(len(content)), ETag: `"v1"`})
+
+	downloadTestArchive(t, target)
+	if data, _ := os.ReadFile(target); string(data) != string(content) {
+		t.Error("unexpected archive content")
+	}
+	if len(requests) != 1 || requests[0] != `bytes=300-|"v1"` {
+		t.Errorf("unexpected requests %q", requests)
+	}
+	if _, err := os.Stat(target + PART_FILE_EXT + PART_INFO_EXT); !os.IsNotExist(err) {
+		t.Error("expected partial download info to be removed")
+	}
+}
+
+func TestDownloadArchiveStartsOverForeignPart(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+	var requests []string
+	archiveServer(t, content, `"v2"`, &requests)
+
+	// a partial file without info, one of another archive and one of an older version of the archive
+	for _, info := range []*PartInfo{nil, {Url: "other", Size: int64(len(content))}, {Url: "archive", Size: int64(len(content)), ETag: `"v1"`}} {
+		requests = nil
+		target := filepath.Join(t.TempDir(), "archive.zip")
+		os.WriteFile(target+PART_FILE_EXT, []byte(strings.Repeat("x", 300)), 0644)
+		if info != nil {
+			WritePartInfo(target+PART_FILE_EXT, info)
+		}
+
+		downloadTestArchive(t, target)
+		if data, _ := os.ReadFile(target); string(data) != string(content) {
+			t.Errorf("part %+v was not replaced", info)
+		}
+	}
+}
+
+func TestDownloadArchiveDoesNotTakeCompletePartWithoutChecksum(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+	var requests []string
+	archiveServer(t, content, "", &requests)
+
+	target := filepath.Join(t.TempDir(), "archive.zip")
+	stale := []byte(strings.Repeat("x", len(content)))
+	os.WriteFile(target+PART_FILE_EXT, stale, 0644)
+	WritePartInfo(target+PART_FILE_EXT, &PartInfo{Url: "archive", Size: int64(len(content))})
+
+	downloadTestArchive(t, target)
+	if data, _ := os.ReadFile(target); string(data) != string(content) {
+		t.Error("stale partial file was taken as the archive")
+	}
+	if len(requests) != 2 || requests[1] != "|" {
+		t.Errorf("expected the download to start over, got requests %q", requests)
+	}
+}
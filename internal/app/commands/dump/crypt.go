@@ -0,0 +1,316 @@
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const AGE_EXT = ".age"
+const ZSTD_EXT = ".zst"
+const DUMP_PASSPHRASE_ENV = "ENONIC_CLI_DUMP_PASSPHRASE"
+
+var ageMagic = []byte("age-encryption.org/")
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+var ENCODE_FLAGS = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "compress, z",
+		Usage: "Compress the downloaded dump with zstd",
+	},
+	cli.StringSliceFlag{
+		Name:  "recipient, R",
+		Usage: "Encrypt the downloaded dump with age to this X25519 public key (age1...), can be repeated",
+	},
+	cli.BoolFlag{
+		Name:  "passphrase",
+		Usage: "Encrypt the downloaded dump with age using a passphrase, read from " + DUMP_PASSPHRASE_ENV + " or prompted for",
+	},
+}
+
+var DECODE_FLAGS = []cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "identity, i",
+		Usage: "File with age identities (AGE-SECRET-KEY-1...) to decrypt an encrypted dump with, can be repeated",
+	},
+}
+
+// encodeDumpArchive compresses and/or encrypts the dump archive, encryption is applied last
+func encodeDumpArchive(src io.Reader, dst io.Writer, compress bool, recipients []age.Recipient) error {
+	encoder, err := newDumpEncoder(dst, compress, recipients)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(encoder, src); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// newDumpEncoder returns a writer compressing and/or encrypting into dst, it writes through when neither is asked for.
+// Closing it flushes the encoded data but leaves dst open.
+func newDumpEncoder(dst io.Writer, compress bool, recipients []age.Recipient) (io.WriteCloser, error) {
+	encoder := &dumpEncoder{Writer: dst}
+
+	if len(recipients) > 0 {
+		encrypted, err := age.Encrypt(encoder.Writer, recipients...)
+		if err != nil {
+			return nil, err
+		}
+		encoder.Writer = encrypted
+		encoder.closers = append(encoder.closers, encrypted)
+	}
+	if compress {
+		compressed, err := zstd.NewWriter(encoder.Writer)
+		if err != nil {
+			return nil, err
+		}
+		encoder.Writer = compressed
+		encoder.closers = append(encoder.closers, compressed)
+	}
+	return encoder, nil
+}
+
+type dumpEncoder struct {
+	io.Writer
+	closers []io.Closer
+}
+
+func (e *dumpEncoder) Close() error {
+	// close the innermost writer first so it flushes into the outer one
+	for i := len(e.closers) - 1; i >= 0; i-- {
+		if err := e.closers[i].Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeDumpArchive reverses encodeDumpArchive based on the content, identities are only requested for encrypted input
+func decodeDumpArchive(src io.Reader, dst io.Writer, identities func() ([]age.Identity, error)) error {
+	decoder, err := newDumpDecoder(src, identities)
+	if err != nil {
+		return err
+	}
+	defer decoder.Close()
+
+	_, err = io.Copy(dst, decoder)
+	return err
+}
+
+// newDumpDecoder returns a reader of the plain archive, decrypted and decompressed depending on the content of src
+func newDumpDecoder(src io.Reader, identities func() ([]age.Identity, error)) (io.ReadCloser, error) {
+	in := bufio.NewReader(src)
+
+	if header, _ := in.Peek(len(ageMagic)); bytes.Equal(header, ageMagic) {
+		ids, err := identities()
+		if err != nil {
+			return nil, err
+		}
+		decrypted, err := age.Decrypt(in, ids...)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decrypt dump")
+		}
+		in = bufio.NewReader(decrypted)
+	}
+
+	if header, _ := in.Peek(len(zstdMagic)); bytes.Equal(header, zstdMagic) {
+		decompressed, err := zstd.NewReader(in)
+		if err != nil {
+			return nil, err
+		}
+		return decompressed.IOReadCloser(), nil
+	}
+	return io.NopCloser(in), nil
+}
+
+// openDumpFile opens the dump archive for reading its plain zip, decoding it on the fly if necessary
+func openDumpFile(path string, identities func() ([]age.Identity, error)) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := newDumpDecoder(file, identities)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &dumpFileReader{decoder, file}, nil
+}
+
+type dumpFileReader struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (r *dumpFileReader) Close() error {
+	r.ReadCloser.Close()
+	return r.file.Close()
+}
+
+// isEncodedDumpArchive tells if the file is encrypted or compressed by the CLI rather than a plain zip
+func isEncodedDumpArchive(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	header := make([]byte, len(ageMagic))
+	read, _ := io.ReadFull(file, header)
+	header = header[:read]
+	return bytes.HasPrefix(header, ageMagic) || bytes.HasPrefix(header, zstdMagic), nil
+}
+
+// decodeDumpFile writes the plain zip of an encoded dump archive to a temporary file,
+// plain archives are returned as they are. The temporary file must be removed with the returned function
+// before exiting, whether the command succeeds or not.
+func decodeDumpFile(c *cli.Context, path string) (string, func(), error) {
+	noop := func() {}
+	encoded, err := isEncodedDumpArchive(path)
+	if err != nil || !encoded {
+		return path, noop, err
+	}
+
+	tmp, err := os.CreateTemp("", "enonic-dump-*.zip")
+	if err != nil {
+		return "", noop, err
+	}
+	tmp.Close()
+
+	if err = decodeDumpFileTo(c, path, tmp.Name()); err != nil {
+		return "", noop, err
+	}
+	return tmp.Name(), func() {
+		os.Remove(tmp.Name())
+	}, nil
+}
+
+// decodeDumpFileTo writes the plain zip of an encoded dump archive to the output file
+func decodeDumpFileTo(c *cli.Context, input, output string) error {
+	src, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	err = decodeDumpArchive(src, dst, identitiesOnce(c))
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(output)
+	}
+	return err
+}
+
+func recipientsFromFlags(c *cli.Context) ([]age.Recipient, error) {
+	var recipients []age.Recipient
+	for _, key := range c.StringSlice("recipient") {
+		recipient, err := age.ParseX25519Recipient(strings.TrimSpace(key))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid recipient '%s'", key)
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	if c.Bool("passphrase") {
+		if len(recipients) > 0 {
+			return nil, errors.New("--passphrase can not be combined with --recipient")
+		}
+		recipient, err := age.NewScryptRecipient(ensurePassphrase(c))
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+func identitiesFromFlags(c *cli.Context) ([]age.Identity, error) {
+	var identities []age.Identity
+	for _, path := range c.StringSlice("identity") {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := age.ParseIdentities(file)
+		file.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid identity file '%s'", path)
+		}
+		identities = append(identities, parsed...)
+	}
+
+	if len(identities) == 0 {
+		// no keys given, the dump must have been encrypted with a passphrase
+		identity, err := age.NewScryptIdentity(ensurePassphrase(c))
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, nil
+}
+
+// identitiesOnce reads the identities from the flags when they are first needed, so that a passphrase is prompted for once
+func identitiesOnce(c *cli.Context) func() ([]age.Identity, error) {
+	var identities []age.Identity
+	return func() ([]age.Identity, error) {
+		if identities == nil {
+			var err error
+			if identities, err = identitiesFromFlags(c); err != nil {
+				return nil, err
+			}
+		}
+		return identities, nil
+	}
+}
+
+func ensurePassphrase(c *cli.Context) string {
+	force := common.IsForceMode(c)
+	return util.PromptPassword("Dump passphrase", os.Getenv(DUMP_PASSPHRASE_ENV), func(val interface{}) error {
+		if len(val.(string)) == 0 {
+			if force {
+				fmt.Fprintf(os.Stderr, "Passphrase can not be empty in non-interactive mode, set it in %s\n", DUMP_PASSPHRASE_ENV)
+				os.Exit(1)
+			}
+			return errors.New("passphrase can not be empty")
+		}
+		return nil
+	})
+}
+
+func encodedFileName(name string, compress, encrypt bool) string {
+	if compress {
+		name += ZSTD_EXT
+	}
+	if encrypt {
+		name += AGE_EXT
+	}
+	return name
+}
+
+func decodedFileName(path string) string {
+	path = strings.TrimSuffix(path, AGE_EXT)
+	path = strings.TrimSuffix(path, ZSTD_EXT)
+	if filepath.Ext(path) != ".zip" {
+		path += ".zip"
+	}
+	return path
+}
@@ -0,0 +1,137 @@
+package dump
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+)
+
+var plainArchive = []byte("PK\x03\x04 plain dump archive content")
+
+func roundTrip(t *testing.T, compress bool, recipients []age.Recipient, identities []age.Identity) []byte {
+	var encoded bytes.Buffer
+	if err := encodeDumpArchive(bytes.NewReader(plainArchive), &encoded, compress, recipients); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	if (compress || len(recipients) > 0) && bytes.Equal(encoded.Bytes(), plainArchive) {
+		t.Fatalf("archive was not encoded")
+	}
+
+	var decoded bytes.Buffer
+	err := decodeDumpArchive(&encoded, &decoded, func() ([]age.Identity, error) {
+		return identities, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	return decoded.Bytes()
+}
+
+func TestDumpArchiveRoundTripX25519(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, compress := range []bool{false, true} {
+		got := roundTrip(t, compress, []age.Recipient{identity.Recipient()}, []age.Identity{identity})
+		if !bytes.Equal(got, plainArchive) {
+			t.Errorf("compress=%v: got %q, want %q", compress, got, plainArchive)
+		}
+	}
+}
+
+func TestDumpArchiveRoundTripPassphrase(t *testing.T) {
+	recipient, _ := age.NewScryptRecipient("secret")
+	recipient.SetWorkFactor(10)
+	identity, _ := age.NewScryptIdentity("secret")
+
+	got := roundTrip(t, true, []age.Recipient{recipient}, []age.Identity{identity})
+	if !bytes.Equal(got, plainArchive) {
+		t.Errorf("got %q, want %q", got, plainArchive)
+	}
+}
+
+func TestDumpArchiveWrongIdentity(t *testing.T) {
+	identity, _ := age.GenerateX25519Identity()
+	other, _ := age.GenerateX25519Identity()
+
+	var encoded bytes.Buffer
+	if err := encodeDumpArchive(bytes.NewReader(plainArchive), &encoded, false, []age.Recipient{identity.Recipient()}); err != nil {
+		t.Fatal(err)
+	}
+	err := decodeDumpArchive(&encoded, &bytes.Buffer{}, func() ([]age.Identity, error) {
+		return []age.Identity{other}, nil
+	})
+	if err == nil {
+		t.Errorf("expected decryption with a wrong identity to fail")
+	}
+}
+
+func TestDumpArchiveCompressOnly(t *testing.T) {
+	got := roundTrip(t, true, nil, nil)
+	if !bytes.Equal(got, plainArchive) {
+		t.Errorf("got %q, want %q", got, plainArchive)
+	}
+}
+
+func TestDecodeDumpArchivePlain(t *testing.T) {
+	var decoded bytes.Buffer
+	err := decodeDumpArchive(bytes.NewReader(plainArchive), &decoded, func() ([]age.Identity, error) {
+		t.Fatalf("identities should not be requested for a plain archive")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(decoded.Bytes(), plainArchive) {
+		t.Errorf("got %q, want %q", decoded.Bytes(), plainArchive)
+	}
+}
+
+func TestIsEncodedDumpArchive(t *testing.T) {
+	dir := t.TempDir()
+
+	plain := filepath.Join(dir, "plain.zip")
+	os.WriteFile(plain, plainArchive, 0644)
+
+	var compressed bytes.Buffer
+	encodeDumpArchive(bytes.NewReader(plainArchive), &compressed, true, nil)
+	zst := filepath.Join(dir, "dump.zip.zst")
+	os.WriteFile(zst, compressed.Bytes(), 0644)
+
+	empty := filepath.Join(dir, "empty.zip")
+	os.WriteFile(empty, nil, 0644)
+
+	for path, want := range map[string]bool{plain: false, zst: true, empty: false} {
+		got, err := isEncodedDumpArchive(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != want {
+			t.Errorf("%s: got %v, want %v", filepath.Base(path), got, want)
+		}
+	}
+}
+
+func TestEncodedFileNames(t *testing.T) {
+	if got := encodedFileName("dump.zip", true, true); got != "dump.zip.zst.age" {
+		t.Errorf("got %s", got)
+	}
+	if got := encodedFileName("dump.zip", false, true); got != "dump.zip.age" {
+		t.Errorf("got %s", got)
+	}
+	for input, want := range map[string]string{
+		"dump.zip.zst.age": "dump.zip",
+		"dump.zip.age":     "dump.zip",
+		"dump.zip":         "dump.zip",
+		"dump.zst":         "dump.zip",
+	} {
+		if got := decodedFileName(input); got != want {
+			t.Errorf("%s: got %s, want %s", input, got, want)
+		}
+	}
+}
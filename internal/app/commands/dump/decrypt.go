@@ -0,0 +1,47 @@
+package dump
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli"
+)
+
+var Decrypt = cli.Command{
+	Name:      "decrypt",
+	Usage:     "Decrypt and decompress a dump archive downloaded with encryption or compression.",
+	ArgsUsage: "<file>",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "File to save the plain zip archive to, defaults to the file name without .age and .zst",
+		},
+		common.FORCE_FLAG,
+	}, DECODE_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		input := c.Args().First()
+		if input == "" {
+			fmt.Fprintln(os.Stderr, "Dump archive to decrypt is required")
+			os.Exit(1)
+		}
+		encoded, err := isEncodedDumpArchive(input)
+		util.Fatal(err, "Could not read dump archive:")
+		if !encoded {
+			fmt.Fprintf(os.Stderr, "'%s' is neither encrypted nor compressed\n", input)
+			os.Exit(1)
+		}
+
+		output := ensureOutputFile(c, decodedFileName(input), common.IsForceMode(c))
+		if output == input {
+			fmt.Fprintln(os.Stderr, "Output file must differ from the input file")
+			os.Exit(1)
+		}
+		util.Fatal(decodeDumpFileTo(c, input, output), "Could not decrypt dump:")
+		fmt.Fprintf(os.Stderr, "Saved plain dump archive to '%s'\n", output)
+
+		return nil
+	},
+}
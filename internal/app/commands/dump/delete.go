@@ -0,0 +1,82 @@
+package dump
+
+import (
+	"bytes"
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+var Delete = cli.Command{
+	Name:      "delete",
+	Aliases:   []string{"del"},
+	Usage:     "Delete one or more dumps.",
+	ArgsUsage: "<dump name>...",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "d",
+			Usage: "Dump name",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		force := common.IsForceMode(c)
+		names := []string(c.Args())
+		if len(names) == 0 {
+			names = []string{ensureNameFlag(c, false, force)}
+		}
+
+		if !force && !util.PromptBool(fmt.Sprintf("Delete %s", strings.Join(names, ", ")), false) {
+			return nil
+		}
+
+		result := deleteDumps(c, names)
+		printDeleteResult(os.Stderr, result)
+		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+
+		if len(result.FailedDumps) > 0 {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func deleteDumps(c *cli.Context, names []string) *DeleteDumpsResult {
+	normalized := make([]string, len(names))
+	for i, name := range names {
+		normalized[i], _ = normalizeName(name)
+	}
+
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(map[string]interface{}{
+		"names": normalized,
+	})
+	req := common.CreateRequest(c, "POST", "system/dump/delete", body)
+	resp := common.SendRequest(c, req, "Deleting dump(s)")
+
+	var result DeleteDumpsResult
+	common.ParseResponse(resp, &result)
+	return &result
+}
+
+func printDeleteResult(out io.Writer, result *DeleteDumpsResult) {
+	fmt.Fprintf(out, "%d Deleted\n", len(result.DeletedDumps))
+	for _, failed := range result.FailedDumps {
+		fmt.Fprintf(out, "Failed to delete \"%s\": %s\n", failed.Name, failed.Reason)
+	}
+}
+
+type DeleteDumpsResult struct {
+	DeletedDumps []string `json:"deletedDumps"`
+	FailedDumps  []struct {
+		Name   string `json:"name"`
+		Reason string `json:"reason"`
+	} `json:"failedDumps"`
+}
@@ -0,0 +1,244 @@
+package dump
+
+import (
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+const NODE_MOVED = "moved"
+const NODE_CHANGED = "changed"
+
+var Diff = cli.Command{
+	Name:      "diff",
+	Usage:     "Compare two dump folders or zip archives node by node.",
+	ArgsUsage: "<from dump> <to dump>",
+	Flags: []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "repo, r",
+			Usage: "Only compare this repository, can be repeated",
+		},
+		cli.StringFlag{
+			Name:  "path, p",
+			Usage: "Only compare nodes at or below this path",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the differences as JSON",
+		},
+	},
+	Action: func(c *cli.Context) error {
+
+		if c.NArg() != 2 {
+			fmt.Fprintln(os.Stderr, "Paths to two dump folders or zip archives are required")
+			os.Exit(1)
+		}
+		fromPath, toPath := c.Args().Get(0), c.Args().Get(1)
+
+		from, err := openDumpReader(fromPath)
+		util.Fatal(err, fmt.Sprintf("Could not open dump '%s':", fromPath))
+		defer from.Close()
+		to, err := openDumpReader(toPath)
+		util.Fatal(err, fmt.Sprintf("Could not open dump '%s':", toPath))
+		defer to.Close()
+
+		branches, err := diffDumps(from, to, c.StringSlice("repo"), c.String("path"))
+		util.Fatal(err, "Could not compare dumps:")
+		result := DumpDiffResult{From: fromPath, To: toPath, Branches: branches}
+
+		if c.Bool("json") {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+		} else {
+			printDumpDiff(os.Stdout, result.Branches)
+		}
+
+		return nil
+	},
+}
+
+// diffDumps compares the branches of the repositories present in either dump, skipping the ones without differences
+func diffDumps(from, to dumpReader, repos []string, pathFilter string) ([]BranchDiff, error) {
+	for _, reader := range []dumpReader{from, to} {
+		if _, err := readDumpInfo(reader); err != nil {
+			return nil, err
+		}
+	}
+	fromBranches := listDumpBranches(from)
+	toBranches := listDumpBranches(to)
+
+	allRepos := make(map[string]map[string]bool)
+	for _, branchesByRepo := range []map[string]map[string]string{fromBranches, toBranches} {
+		for repo, branches := range branchesByRepo {
+			if len(repos) > 0 && !slices.Contains(repos, repo) {
+				continue
+			}
+			if allRepos[repo] == nil {
+				allRepos[repo] = make(map[string]bool)
+			}
+			for branch := range branches {
+				allRepos[repo][branch] = true
+			}
+		}
+	}
+
+	diffs := make([]BranchDiff, 0)
+	for _, repo := range slices.Sorted(maps.Keys(allRepos)) {
+		for _, branch := range slices.Sorted(maps.Keys(allRepos[repo])) {
+			fromNodes, err := readBranchNodes(from, fromBranches[repo][branch])
+			if err != nil {
+				return nil, err
+			}
+			toNodes, err := readBranchNodes(to, toBranches[repo][branch])
+			if err != nil {
+				return nil, err
+			}
+
+			diff := diffBranchNodes(fromNodes, toNodes, pathFilter)
+			if len(diff.Added)+len(diff.Removed)+len(diff.Modified) > 0 {
+				diff.Repository = repo
+				diff.Branch = branch
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// readBranchNodes returns the nodes of a branch file by id, a missing branch has no nodes
+func readBranchNodes(reader dumpReader, name string) (map[string]DumpBranchEntry, error) {
+	nodes := make(map[string]DumpBranchEntry)
+	if name == "" {
+		return nodes, nil
+	}
+	err := readBranchEntries(reader, name, func(entry *DumpBranchEntry) {
+		nodes[entry.NodeId] = *entry
+	})
+	return nodes, err
+}
+
+func diffBranchNodes(from, to map[string]DumpBranchEntry, pathFilter string) BranchDiff {
+	diff := BranchDiff{
+		Added:    make([]NodeRef, 0),
+		Removed:  make([]NodeRef, 0),
+		Modified: make([]NodeChange, 0),
+	}
+
+	for id, fromNode := range from {
+		toNode, found := to[id]
+		if !found {
+			if matchesNodePath(fromNode.NodePath, pathFilter) {
+				diff.Removed = append(diff.Removed, NodeRef{id, fromNode.NodePath})
+			}
+			continue
+		}
+		if !matchesNodePath(fromNode.NodePath, pathFilter) && !matchesNodePath(toNode.NodePath, pathFilter) {
+			continue
+		}
+
+		change := NodeChange{Id: id, Path: toNode.NodePath, Changes: make([]string, 0)}
+		if fromNode.NodePath != toNode.NodePath {
+			change.PreviousPath = fromNode.NodePath
+			change.Changes = append(change.Changes, NODE_MOVED)
+		}
+		if nodeContentKey(&fromNode) != nodeContentKey(&toNode) {
+			change.Changes = append(change.Changes, NODE_CHANGED)
+		}
+		if len(change.Changes) > 0 {
+			diff.Modified = append(diff.Modified, change)
+		}
+	}
+	for id, toNode := range to {
+		if _, found := from[id]; !found && matchesNodePath(toNode.NodePath, pathFilter) {
+			diff.Added = append(diff.Added, NodeRef{id, toNode.NodePath})
+		}
+	}
+
+	sortNodeRefs(diff.Added)
+	sortNodeRefs(diff.Removed)
+	sort.Slice(diff.Modified, func(i, j int) bool {
+		return diff.Modified[i].Path < diff.Modified[j].Path
+	})
+	return diff
+}
+
+// nodeContentKey identifies the content of a node version, the blob key is a hash of it when present
+func nodeContentKey(entry *DumpBranchEntry) string {
+	if entry.Version.NodeBlobKey != "" {
+		return entry.Version.NodeBlobKey
+	}
+	return entry.Version.Id
+}
+
+func matchesNodePath(nodePath, pathFilter string) bool {
+	if pathFilter == "" || pathFilter == "/" {
+		return true
+	}
+	pathFilter = strings.TrimSuffix(pathFilter, "/")
+	return nodePath == pathFilter || strings.HasPrefix(nodePath, pathFilter+"/")
+}
+
+func sortNodeRefs(refs []NodeRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Path < refs[j].Path
+	})
+}
+
+func printDumpDiff(out io.Writer, diffs []BranchDiff) {
+	if len(diffs) == 0 {
+		fmt.Fprintln(os.Stderr, "No differences found")
+		return
+	}
+
+	for i, diff := range diffs {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "%s:%s (%d added, %d removed, %d modified)\n", diff.Repository, diff.Branch, len(diff.Added), len(diff.Removed), len(diff.Modified))
+		for _, node := range diff.Added {
+			fmt.Fprintf(out, "+ %s [%s]\n", node.Path, node.Id)
+		}
+		for _, node := range diff.Removed {
+			fmt.Fprintf(out, "- %s [%s]\n", node.Path, node.Id)
+		}
+		for _, node := range diff.Modified {
+			if node.PreviousPath != "" {
+				fmt.Fprintf(out, "~ %s -> %s [%s] %s\n", node.PreviousPath, node.Path, node.Id, strings.Join(node.Changes, ", "))
+			} else {
+				fmt.Fprintf(out, "~ %s [%s] %s\n", node.Path, node.Id, strings.Join(node.Changes, ", "))
+			}
+		}
+	}
+}
+
+type DumpDiffResult struct {
+	From     string       `json:"from"`
+	To       string       `json:"to"`
+	Branches []BranchDiff `json:"branches"`
+}
+
+type BranchDiff struct {
+	Repository string       `json:"repository"`
+	Branch     string       `json:"branch"`
+	Added      []NodeRef    `json:"added"`
+	Removed    []NodeRef    `json:"removed"`
+	Modified   []NodeChange `json:"modified"`
+}
+
+type NodeRef struct {
+	Id   string `json:"id"`
+	Path string `json:"path"`
+}
+
+type NodeChange struct {
+	Id           string   `json:"id"`
+	Path         string   `json:"path"`
+	PreviousPath string   `json:"previousPath,omitempty"`
+	Changes      []string `json:"changes"`
+}
@@ -0,0 +1,93 @@
+package dump
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffDumps(t *testing.T) {
+	fromDir := filepath.Join(t.TempDir(), "from")
+	writeTestDump(t, fromDir)
+
+	toDir := filepath.Join(t.TempDir(), "to")
+	writeTestDump(t, toDir)
+	writeTarGz(t, filepath.Join(toDir, "meta", "com.enonic.cms.default", "draft", DUMP_BRANCH_FILE),
+		nodeEntry("1", "/content", "a"), nodeEntry("2", "/content/site-renamed", "e"), nodeEntry("4", "/content/new", "f"))
+
+	from, _ := openDumpReader(fromDir)
+	to, _ := openDumpReader(toDir)
+
+	diffs, err := diffDumps(from, to, nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diffs) != 1 {
+		t.Fatalf("expected only the draft branch to differ, got %+v", diffs)
+	}
+
+	diff := diffs[0]
+	if diff.Repository != "com.enonic.cms.default" || diff.Branch != "draft" {
+		t.Errorf("unexpected branch: %s:%s", diff.Repository, diff.Branch)
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != (NodeRef{"4", "/content/new"}) {
+		t.Errorf("unexpected added nodes: %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != (NodeRef{"3", "/content/site/page"}) {
+		t.Errorf("unexpected removed nodes: %+v", diff.Removed)
+	}
+	if len(diff.Modified) != 1 || diff.Modified[0].PreviousPath != "/content/site" ||
+		strings.Join(diff.Modified[0].Changes, ",") != NODE_MOVED+","+NODE_CHANGED {
+		t.Errorf("unexpected modified nodes: %+v", diff.Modified)
+	}
+
+	out := new(bytes.Buffer)
+	printDumpDiff(out, diffs)
+	if !strings.Contains(out.String(), "~ /content/site -> /content/site-renamed [2] moved, changed") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestDiffDumpsFilters(t *testing.T) {
+	fromDir := filepath.Join(t.TempDir(), "from")
+	writeTestDump(t, fromDir)
+	toDir := filepath.Join(t.TempDir(), "to")
+	writeTestDump(t, toDir)
+	os.RemoveAll(filepath.Join(toDir, "meta", "system-repo"))
+	writeTarGz(t, filepath.Join(toDir, "meta", "com.enonic.cms.default", "master", DUMP_BRANCH_FILE),
+		nodeEntry("1", "/content", "changed"), nodeEntry("2", "/content/site", "b"), nodeEntry("5", "/other", "x"))
+
+	from, _ := openDumpReader(fromDir)
+	to, _ := openDumpReader(toDir)
+
+	diffs, _ := diffDumps(from, to, []string{"system-repo"}, "")
+	if len(diffs) != 1 || len(diffs[0].Removed) != 1 || diffs[0].Removed[0].Path != "/" {
+		t.Errorf("expected removed system-repo root only, got %+v", diffs)
+	}
+
+	diffs, _ = diffDumps(from, to, []string{"com.enonic.cms.default"}, "/content/")
+	if len(diffs) != 1 || len(diffs[0].Added) != 0 || len(diffs[0].Modified) != 1 || diffs[0].Modified[0].Path != "/content" {
+		t.Errorf("expected only /content to be modified, got %+v", diffs)
+	}
+}
+
+func TestMatchesNodePath(t *testing.T) {
+	cases := []struct {
+		path, filter string
+		want         bool
+	}{
+		{"/content/site", "", true},
+		{"/content/site", "/", true},
+		{"/content/site", "/content", true},
+		{"/content", "/content/", true},
+		{"/contents", "/content", false},
+		{"/other", "/content", false},
+	}
+	for _, tc := range cases {
+		if got := matchesNodePath(tc.path, tc.filter); got != tc.want {
+			t.Errorf("matchesNodePath(%q, %q) = %v, want %v", tc.path, tc.filter, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package dump
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/commands/remote"
+	"cli-enonic/internal/app/commands/sandbox"
+	"cli-enonic/internal/app/commands/storage"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"filippo.io/age"
+	"github.com/urfave/cli"
+)
+
+var Download = cli.Command{
+	Name:      "download",
+	Usage:     "Download a dump as a zip archive.",
+	ArgsUsage: "<dump name>",
+	Flags: append(append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "d",
+			Usage: "Dump name",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "File or s3://bucket/prefix/ url to save the dump to, defaults to <dump name>.zip with .zst and .age added when compressed and encrypted",
+		},
+		common.SANDBOX_FLAG,
+		storage.PROFILE_FLAG,
+		common.FORCE_FLAG,
+	}, ENCODE_FLAGS...), common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		if c.NArg() > 0 {
+			c.Set("d", c.Args().First())
+		}
+		force := common.IsForceMode(c)
+		compress := c.Bool("compress")
+		recipients, err := recipientsFromFlags(c)
+		util.Fatal(err, "Invalid argument:")
+		encode := compress || len(recipients) > 0
+
+		if sandboxName := sandbox.EnsureSandboxFlag(c); sandboxName != "" {
+			name := ensureLocalDumpName(c, getSandboxDumpDir(sandboxName))
+			location := ensureOutputLocation(c, encodedFileName(name+".zip", compress, len(recipients) > 0), force)
+			storage.SaveFile(c, location, sandboxDumpSource(sandboxName, name), func(output string) {
+				err := writeEncodedFile(output, compress, recipients, func(w io.Writer) error {
+					return writeDumpFromDir(getSandboxDumpDir(sandboxName), name, w)
+				})
+				util.Fatal(err, "Could not copy dump:")
+			})
+			fmt.Fprintf(os.Stderr, "Copied dump \"%s\" from sandbox \"%s\" to '%s'\n", name, sandboxName, location)
+			return nil
+		}
+
+		name, _ := normalizeName(ensureNameFlag(c, false, force))
+		location := ensureOutputLocation(c, encodedFileName(name+".zip", compress, len(recipients) > 0), force)
+		var source string
+		if storage.IsStorageUrl(location) {
+			source = remoteDumpSource(c, name)
+		}
+		storage.SaveFile(c, location, source, func(output string) {
+			if encode {
+				// the plain archive is encoded while it is downloaded so that it never touches the disk
+				util.Fatal(downloadEncodedDump(c, name, output, compress, recipients), "Could not download dump:")
+				return
+			}
+
+			checksum, err := downloadDump(c, name, output)
+			util.Fatal(err, "Could not download dump:")
+
+			if err = verifyChecksum(output, checksum); err != nil {
+				os.Remove(output)
+				util.Fatal(err, "Downloaded dump is corrupted:")
+			}
+		})
+		fmt.Fprintf(os.Stderr, "Downloaded dump \"%s\" to '%s'\n", name, location)
+
+		return nil
+	},
+}
+
+func ensureOutputFile(c *cli.Context, defaultOutput string, force bool) string {
+	output := c.String("output")
+	if output == "" {
+		output = defaultOutput
+	}
+	if _, err := os.Stat(output); err == nil && !force && !util.PromptBool(fmt.Sprintf("File '%s' already exists. Overwrite", output), false) {
+		os.Exit(1)
+	}
+	return output
+}
+
+// ensureOutputLocation resolves the output to an object in the storage when it is a storage url
+// remoteDumpSource describes the dump on the remote, a dump made again under the same name is another source
+func remoteDumpSource(c *cli.Context, name string) string {
+	source := fmt.Sprintf("dump \"%s\" of %s", name, remote.GetActiveRemote().Url.String())
+	for _, dump := range fetchDumpList(c).Dumps {
+		if dump.Name == name {
+			return fmt.Sprintf("%s made %s", source, dump.Timestamp.Format(time.RFC3339))
+		}
+	}
+	return source
+}
+
+// sandboxDumpSource describes the dump in the sandbox by the last change of its folder
+func sandboxDumpSource(sandboxName, name string) string {
+	source := fmt.Sprintf("dump \"%s\" of sandbox \"%s\"", name, sandboxName)
+	if info, err := os.Stat(filepath.Join(getSandboxDumpDir(sandboxName), name)); err == nil {
+		return fmt.Sprintf("%s changed %s", source, info.ModTime().Format(time.RFC3339))
+	}
+	return source
+}
+
+func ensureOutputLocation(c *cli.Context, defaultOutput string, force bool) string {
+	output := c.String("output")
+	if !storage.IsStorageUrl(output) {
+		return ensureOutputFile(c, defaultOutput, force)
+	}
+	return storage.EnsureLocation(c, output, defaultOutput, force)
+}
+
+// downloadEncodedDump streams the dump through the encoder into the output file.
+// Unlike plain downloads it can not be resumed, an interrupted download starts over.
+func downloadEncodedDump(c *cli.Context, name, output string, compress bool, recipients []age.Recipient) error {
+	return writeEncodedFile(output, compress, recipients, func(w io.Writer) error {
+		expected, actual, err := streamDump(c, name, w)
+		if err != nil {
+			return err
+		}
+		return verifyStreamChecksum(actual, expected)
+	})
+}
+
+// writeEncodedFile passes a writer compressing and/or encrypting into the output file to write.
+// The output is written under another name until it is complete and removed if write fails.
+func writeEncodedFile(output string, compress bool, recipients []age.Recipient, write func(w io.Writer) error) error {
+	partFile := output + common.PART_FILE_EXT
+	file, err := os.Create(partFile)
+	if err != nil {
+		return err
+	}
+
+	encoder, err := newDumpEncoder(file, compress, recipients)
+	if err == nil {
+		if err = write(encoder); err == nil {
+			err = encoder.Close()
+		}
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partFile)
+		return err
+	}
+	return os.Rename(partFile, output)
+}
+
+func ensureLocalDumpName(c *cli.Context, dumpDir string) string {
+	name, _ := normalizeName(c.String("d"))
+	if name != "" && dumpExistsLocally(dumpDir, name) {
+		return name
+	}
+
+	existing := listLocalDumpNames(dumpDir)
+	if len(existing) == 0 {
+		fmt.Fprintf(os.Stderr, "No existing dumps found in '%s'\n", dumpDir)
+		os.Exit(1)
+	}
+	if common.IsForceMode(c) {
+		fmt.Fprintf(os.Stderr, "Dump with name '%s' can not be found.\n", name)
+		os.Exit(1)
+	}
+	selected, _, err := util.PromptSelect(&util.SelectOptions{
+		Message: "Select dump",
+		Options: existing,
+	})
+	util.Fatal(err, "Could not select dump: ")
+	name, _ = normalizeName(selected)
+	return name
+}
+
+// writeDumpFromDir archives the dump folder into the writer, or copies the archive if the dump is already zipped
+func writeDumpFromDir(dumpDir, name string, w io.Writer) error {
+	if archive, err := os.Open(filepath.Join(dumpDir, name+".zip")); err == nil {
+		defer archive.Close()
+		_, err = io.Copy(w, archive)
+		return err
+	}
+	return common.ArchiveDir(filepath.Join(dumpDir, name), w)
+}
@@ -1,10 +1,12 @@
 package dump
 
 import (
+	"cli-enonic/internal/app/commands/common"
 	"cli-enonic/internal/app/util"
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -17,6 +19,50 @@ func All() []cli.Command {
 		Upgrade,
 		Load,
 		List,
+		Download,
+		Upload,
+		Migrate,
+		Inspect,
+		Diff,
+		Delete,
+		Prune,
+		Decrypt,
+	}
+}
+
+var REPO_FILTER_FLAGS = []cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "repo, r",
+		Usage: "Only include this repository, can be repeated",
+	},
+	cli.StringSliceFlag{
+		Name:  "exclude-repo",
+		Usage: "Leave this repository out, can be repeated",
+	},
+}
+
+func validateRepoFilterFlags(c *cli.Context) error {
+	included, excluded := c.StringSlice("repo"), c.StringSlice("exclude-repo")
+	if len(included) == 0 && len(excluded) == 0 {
+		return nil
+	}
+	if common.IsCompatMode(c) {
+		return errors.New("--repo and --exclude-repo are not supported in compat mode")
+	}
+	for _, repo := range included {
+		if slices.Contains(excluded, repo) {
+			return errors.Errorf("repository '%s' can not be both included and excluded", repo)
+		}
+	}
+	return nil
+}
+
+func addRepoFilterParams(c *cli.Context, params map[string]interface{}) {
+	if included := c.StringSlice("repo"); len(included) > 0 {
+		params["repositories"] = included
+	}
+	if excluded := c.StringSlice("exclude-repo"); len(excluded) > 0 {
+		params["excludeRepositories"] = excluded
 	}
 }
 
@@ -0,0 +1,77 @@
+package dump
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func newRepoFilterCtx(compat string, included, excluded []string) *cli.Context {
+	fs := flag.NewFlagSet("test", 0)
+	fs.String("compat", "", "")
+	fs.Var(&cli.StringSlice{}, "repo", "")
+	fs.Var(&cli.StringSlice{}, "exclude-repo", "")
+	if compat != "" {
+		fs.Set("compat", compat)
+	}
+	for _, repo := range included {
+		fs.Set("repo", repo)
+	}
+	for _, repo := range excluded {
+		fs.Set("exclude-repo", repo)
+	}
+	return cli.NewContext(nil, fs, nil)
+}
+
+func TestAddRepoFilterParams(t *testing.T) {
+	c := newRepoFilterCtx("", []string{"com.enonic.cms.a", "com.enonic.cms.b"}, []string{"system.auditlog"})
+
+	params := map[string]interface{}{}
+	addRepoFilterParams(c, params)
+
+	if !reflect.DeepEqual(params["repositories"], []string{"com.enonic.cms.a", "com.enonic.cms.b"}) {
+		t.Errorf("unexpected repositories: %v", params["repositories"])
+	}
+	if !reflect.DeepEqual(params["excludeRepositories"], []string{"system.auditlog"}) {
+		t.Errorf("unexpected excludeRepositories: %v", params["excludeRepositories"])
+	}
+}
+
+func TestAddRepoFilterParams_None(t *testing.T) {
+	params := map[string]interface{}{}
+	addRepoFilterParams(newRepoFilterCtx("", nil, nil), params)
+
+	if len(params) != 0 {
+		t.Errorf("expected no params without filters, got %v", params)
+	}
+}
+
+func TestValidateRepoFilterFlags(t *testing.T) {
+	if err := validateRepoFilterFlags(newRepoFilterCtx("", []string{"a"}, []string{"b"})); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateRepoFilterFlags(newRepoFilterCtx("", []string{"a"}, []string{"a"})); err == nil {
+		t.Error("expected error for a repository both included and excluded")
+	}
+	if err := validateRepoFilterFlags(newRepoFilterCtx("7", []string{"a"}, nil)); err == nil {
+		t.Error("expected error in compat mode")
+	}
+	if err := validateRepoFilterFlags(newRepoFilterCtx("7", nil, nil)); err != nil {
+		t.Errorf("unexpected error without filters in compat mode: %v", err)
+	}
+}
+
+func TestLoadWarning(t *testing.T) {
+	if msg := loadWarning(newRepoFilterCtx("", nil, nil)); !strings.Contains(msg, "delete all existing repositories that are") {
+		t.Errorf("unexpected warning: %s", msg)
+	}
+	if msg := loadWarning(newRepoFilterCtx("", []string{"com.enonic.cms.a"}, nil)); !strings.Contains(msg, "delete repositories com.enonic.cms.a if") {
+		t.Errorf("unexpected warning: %s", msg)
+	}
+	if msg := loadWarning(newRepoFilterCtx("", nil, []string{"system.auditlog"})); !strings.Contains(msg, "except system.auditlog") {
+		t.Errorf("unexpected warning: %s", msg)
+	}
+}
@@ -0,0 +1,260 @@
+package dump
+
+import (
+	"cli-enonic/internal/app/util"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var Inspect = cli.Command{
+	Name:      "inspect",
+	Usage:     "Show the content of a dump folder or zip archive without loading it.",
+	ArgsUsage: "<path>",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "target-version, t",
+			Usage: "XP version the dump is going to be loaded into, to check if it needs an upgrade",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the result as JSON",
+		},
+	},
+	Action: func(c *cli.Context) error {
+
+		dumpPath := c.Args().First()
+		if dumpPath == "" {
+			fmt.Fprintln(os.Stderr, "Path to a dump folder or zip archive is required")
+			os.Exit(1)
+		}
+
+		reader, err := openDumpReader(dumpPath)
+		util.Fatal(err, "Could not open dump:")
+		defer reader.Close()
+
+		result, err := inspectDump(reader)
+		util.Fatal(err, "Could not inspect dump:")
+		result.Path = dumpPath
+
+		if target := c.String("target-version"); target != "" {
+			result.Upgrade, err = checkDumpUpgrade(result.XpVersion, result.ModelVersion, target)
+			util.Fatal(err, "Invalid argument:")
+		}
+
+		if c.Bool("json") {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+		} else {
+			printDumpInspection(os.Stdout, result)
+		}
+
+		return nil
+	},
+}
+
+func inspectDump(reader dumpReader) (*DumpInspection, error) {
+	info, err := readDumpInfo(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DumpInspection{
+		XpVersion:    info.XpVersion,
+		ModelVersion: info.ModelVersion,
+		Timestamp:    info.Timestamp,
+		Repositories: make([]RepositoryInspection, 0),
+	}
+	for _, f := range reader.Files() {
+		result.Size += f.Size
+		if isBinaryBlob(f.Path) {
+			result.BinarySize += f.Size
+		}
+	}
+
+	dumpedVersions := make(map[string]int64)
+	if info.SystemDumpResult != nil {
+		for _, repo := range info.SystemDumpResult.Repositories {
+			dumpedVersions[repo.RepositoryId] = repo.Versions
+		}
+	}
+
+	repos := listDumpBranches(reader)
+	for _, repoId := range slices.Sorted(maps.Keys(repos)) {
+		repo := RepositoryInspection{Id: repoId, Branches: make([]BranchInspection, 0)}
+		for _, branch := range slices.Sorted(maps.Keys(repos[repoId])) {
+			var nodes int64
+			if err = readBranchEntries(reader, repos[repoId][branch], func(*DumpBranchEntry) { nodes++ }); err != nil {
+				return nil, err
+			}
+			repo.Branches = append(repo.Branches, BranchInspection{Name: branch, Nodes: nodes})
+		}
+
+		if repo.Versions, err = countDumpVersions(reader, repoId); err != nil {
+			return nil, err
+		} else if repo.Versions < 0 {
+			// dumps made with skip-versions have no versions file, the dump result still knows the count
+			repo.Versions = dumpedVersions[repoId]
+		}
+		result.Repositories = append(result.Repositories, repo)
+	}
+
+	return result, nil
+}
+
+// countDumpVersions returns the number of versions in the versions file of the repository or -1 if there is none
+func countDumpVersions(reader dumpReader, repoId string) (int64, error) {
+	name := strings.Join([]string{DUMP_META_DIR, repoId, DUMP_VERSIONS_FILE}, "/")
+	var versions int64
+	err := readTarGzEntries(reader, name, func(data []byte) error {
+		var entry DumpVersionsEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return errors.Wrapf(err, "could not parse version entry in %s", name)
+		}
+		versions += int64(len(entry.Versions))
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return -1, nil
+	}
+	return versions, err
+}
+
+func isBinaryBlob(filePath string) bool {
+	parts := strings.Split(filePath, "/")
+	if len(parts) < 2 || parts[0] != DUMP_DATA_DIR {
+		return false
+	}
+	for _, part := range parts[1 : len(parts)-1] {
+		if part == DUMP_BINARY_SEGMENT {
+			return true
+		}
+	}
+	return false
+}
+
+// dumpModelVersions lists the dump model version each XP version expects, by the XP version that introduced it
+var dumpModelVersions = []struct {
+	xpVersion    string
+	modelVersion string
+}{
+	{"7.0.0", "8.0.0"},
+	{"7.3.0", "9.0.0"},
+}
+
+// expectedModelVersion returns the dump model version the XP version expects or nil if it is older than the known ones
+func expectedModelVersion(target *semver.Version) *semver.Version {
+	var expected *semver.Version
+	for _, known := range dumpModelVersions {
+		if target.LessThan(semver.MustParse(known.xpVersion)) {
+			break
+		}
+		expected = semver.MustParse(known.modelVersion)
+	}
+	return expected
+}
+
+// checkDumpUpgrade tells if a dump needs to be upgraded before loading it into the target XP version.
+// Dumps of an older major XP version or with an older model version than the target expects need an upgrade,
+// dumps of a newer XP version can not be loaded at all.
+func checkDumpUpgrade(dumpVersion, modelVersion, targetVersion string) (*DumpUpgradeCheck, error) {
+	target, err := semver.NewVersion(targetVersion)
+	if err != nil {
+		return nil, errors.Errorf("'%s' is not a valid XP version", targetVersion)
+	}
+	check := &DumpUpgradeCheck{TargetVersion: target.String()}
+
+	source, err := semver.NewVersion(dumpVersion)
+	model, modelErr := semver.NewVersion(modelVersion)
+	if err != nil || modelErr != nil {
+		check.Required = true
+		check.Message = "dump has no valid version information, it was likely made by an old XP version and needs an upgrade"
+		return check, nil
+	}
+	expected := expectedModelVersion(target)
+
+	switch {
+	case source.GreaterThan(target):
+		check.Message = fmt.Sprintf("dump was made by XP %s which is newer than %s, it can not be loaded", source, target)
+	case source.Major() < target.Major():
+		check.Required = true
+		check.Message = fmt.Sprintf("dump was made by XP %s, run 'enonic dump upgrade' or load it with --upgrade", source)
+	case expected != nil && model.LessThan(expected):
+		check.Required = true
+		check.Message = fmt.Sprintf("dump has model version %s and XP %s expects %s, run 'enonic dump upgrade' or load it with --upgrade", model, target, expected)
+	default:
+		check.Message = "no upgrade needed"
+	}
+	return check, nil
+}
+
+func printDumpInspection(out io.Writer, result *DumpInspection) {
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "Dump:\t%s\n", result.Path)
+	fmt.Fprintf(writer, "XP version:\t%s\n", result.XpVersion)
+	fmt.Fprintf(writer, "Model version:\t%s\n", result.ModelVersion)
+	fmt.Fprintf(writer, "Created:\t%s\n", result.Timestamp.Format(time.RFC3339))
+	fmt.Fprintf(writer, "Size:\t%s (binaries %s)\n", formatBytes(result.Size), formatBytes(result.BinarySize))
+	if result.Upgrade != nil {
+		required := "not required"
+		if result.Upgrade.Required {
+			required = "required"
+		}
+		fmt.Fprintf(writer, "Upgrade to %s:\t%s, %s\n", result.Upgrade.TargetVersion, required, result.Upgrade.Message)
+	}
+	writer.Flush()
+	fmt.Fprintln(out)
+
+	writer = tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, strings.Join([]string{"REPOSITORY", "VERSIONS", "BRANCH", "NODES"}, "\t"))
+	for _, repo := range result.Repositories {
+		if len(repo.Branches) == 0 {
+			fmt.Fprintf(writer, "%s\t%d\t-\t-\n", repo.Id, repo.Versions)
+		}
+		for i, branch := range repo.Branches {
+			if i == 0 {
+				fmt.Fprintf(writer, "%s\t%d\t%s\t%d\n", repo.Id, repo.Versions, branch.Name, branch.Nodes)
+			} else {
+				fmt.Fprintf(writer, "\t\t%s\t%d\n", branch.Name, branch.Nodes)
+			}
+		}
+	}
+	writer.Flush()
+}
+
+type DumpInspection struct {
+	Path         string                 `json:"path"`
+	XpVersion    string                 `json:"xpVersion"`
+	ModelVersion string                 `json:"modelVersion"`
+	Timestamp    time.Time              `json:"timestamp"`
+	Size         int64                  `json:"size"`
+	BinarySize   int64                  `json:"binarySize"`
+	Repositories []RepositoryInspection `json:"repositories"`
+	Upgrade      *DumpUpgradeCheck      `json:"upgrade,omitempty"`
+}
+
+type RepositoryInspection struct {
+	Id       string             `json:"id"`
+	Versions int64              `json:"versions"`
+	Branches []BranchInspection `json:"branches"`
+}
+
+type BranchInspection struct {
+	Name  string `json:"name"`
+	Nodes int64  `json:"nodes"`
+}
+
+type DumpUpgradeCheck struct {
+	TargetVersion string `json:"targetVersion"`
+	Required      bool   `json:"required"`
+	Message       string `json:"message"`
+}
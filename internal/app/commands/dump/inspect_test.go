@@ -0,0 +1,158 @@
+package dump
+
+import (
+	"archive/tar"
+	"bytes"
+	"cli-enonic/internal/app/commands/common"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTarGz(t *testing.T, path string, entries ...string) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	writer := tar.NewWriter(gz)
+	for i, entry := range entries {
+		writer.WriteHeader(&tar.Header{Name: fmt.Sprintf("%d.json", i), Mode: 0644, Size: int64(len(entry)), Typeflag: tar.TypeReg})
+		writer.Write([]byte(entry))
+	}
+	writer.Close()
+	gz.Close()
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func nodeEntry(id, nodePath, blobKey string) string {
+	return fmt.Sprintf(`{"nodeId":"%s","nodePath":"%s","version":{"id":"v-%s","nodeBlobKey":"%s"}}`, id, nodePath, blobKey, blobKey)
+}
+
+func writeTestDump(t *testing.T, dir string) {
+	t.Helper()
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, DUMP_INFO_FILE), []byte(`{
+		"xpVersion":"7.14.0","modelVersion":"9.0.0","timestamp":"2024-01-02T10:00:00Z",
+		"systemDumpResult":{"repositories":[{"repositoryId":"system-repo","versions":42}]}
+	}`), 0644)
+
+	writeTarGz(t, filepath.Join(dir, "meta", "com.enonic.cms.default", "master", DUMP_BRANCH_FILE),
+		nodeEntry("1", "/content", "a"), nodeEntry("2", "/content/site", "b"))
+	writeTarGz(t, filepath.Join(dir, "meta", "com.enonic.cms.default", "draft", DUMP_BRANCH_FILE),
+		nodeEntry("1", "/content", "a"), nodeEntry("2", "/content/site", "c"), nodeEntry("3", "/content/site/page", "d"))
+	writeTarGz(t, filepath.Join(dir, "meta", "com.enonic.cms.default", DUMP_VERSIONS_FILE),
+		`{"nodeId":"1","versions":[{},{}]}`, `{"nodeId":"2","versions":[{},{},{}]}`)
+	writeTarGz(t, filepath.Join(dir, "meta", "system-repo", "master", DUMP_BRANCH_FILE),
+		nodeEntry("root", "/", "r"))
+
+	os.MkdirAll(filepath.Join(dir, "data", "cms", "binary", "ab"), 0755)
+	os.WriteFile(filepath.Join(dir, "data", "cms", "binary", "ab", "blob1"), []byte("0123456789"), 0644)
+	os.MkdirAll(filepath.Join(dir, "data", "cms", "node"), 0755)
+	os.WriteFile(filepath.Join(dir, "data", "cms", "node", "blob2"), []byte("01234"), 0644)
+}
+
+func TestInspectDumpFolder(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mydump")
+	writeTestDump(t, dir)
+
+	reader, err := openDumpReader(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer reader.Close()
+
+	result, err := inspectDump(reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.XpVersion != "7.14.0" || result.ModelVersion != "9.0.0" {
+		t.Errorf("unexpected versions: %s / %s", result.XpVersion, result.ModelVersion)
+	}
+	if result.BinarySize != 10 {
+		t.Errorf("expected binary size 10, got %d", result.BinarySize)
+	}
+	if len(result.Repositories) != 2 {
+		t.Fatalf("expected 2 repositories, got %+v", result.Repositories)
+	}
+
+	cms := result.Repositories[0]
+	if cms.Id != "com.enonic.cms.default" || cms.Versions != 5 {
+		t.Errorf("unexpected repository: %+v", cms)
+	}
+	if len(cms.Branches) != 2 || cms.Branches[0] != (BranchInspection{"draft", 3}) || cms.Branches[1] != (BranchInspection{"master", 2}) {
+		t.Errorf("unexpected branches: %+v", cms.Branches)
+	}
+
+	system := result.Repositories[1]
+	if system.Versions != 42 {
+		t.Errorf("expected versions from the dump result when there is no versions file, got %d", system.Versions)
+	}
+}
+
+func TestInspectDumpArchive(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mydump")
+	writeTestDump(t, dir)
+
+	archive := filepath.Join(t.TempDir(), "mydump.zip")
+	file, _ := os.Create(archive)
+	if err := common.ArchiveDir(dir, file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	file.Close()
+
+	reader, err := openDumpReader(archive)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer reader.Close()
+
+	result, err := inspectDump(reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Repositories) != 2 || result.Repositories[0].Branches[0].Nodes != 3 {
+		t.Errorf("unexpected result: %+v", result.Repositories)
+	}
+}
+
+func TestInspectNotADump(t *testing.T) {
+	reader, _ := openDumpReader(t.TempDir())
+	if _, err := inspectDump(reader); err == nil || !strings.Contains(err.Error(), DUMP_INFO_FILE) {
+		t.Errorf("expected missing %s error, got %v", DUMP_INFO_FILE, err)
+	}
+}
+
+func TestCheckDumpUpgrade(t *testing.T) {
+	cases := []struct {
+		dump, model, target string
+		required            bool
+		message             string
+	}{
+		{"7.14.0", "9.0.0", "8.0.0", true, "run 'enonic dump upgrade'"},
+		{"7.14.0", "9.0.0", "7.15.1", false, "no upgrade needed"},
+		{"7.14.0", "9.0.0", "7.10.0", false, "newer than"},
+		{"7.2.0", "8.0.0", "7.15.1", true, "expects 9.0.0"},
+		{"7.2.0", "8.0.0", "7.2.5", false, "no upgrade needed"},
+		{"7.14.0", "", "7.15.1", true, "no valid version"},
+		{"", "", "7.10.0", true, "no valid version"},
+	}
+	for _, tc := range cases {
+		check, err := checkDumpUpgrade(tc.dump, tc.model, tc.target)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if check.Required != tc.required || !strings.Contains(check.Message, tc.message) {
+			t.Errorf("checkDumpUpgrade(%q, %q) = %+v", tc.dump, tc.target, check)
+		}
+	}
+
+	if _, err := checkDumpUpgrade("7.14.0", "9.0.0", "latest"); err == nil {
+		t.Error("expected error for invalid target version")
+	}
+}
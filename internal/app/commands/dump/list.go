@@ -5,25 +5,58 @@ import (
 	"cli-enonic/internal/app/util"
 	"fmt"
 	"github.com/urfave/cli"
+	"io"
 	"os"
+	"strings"
+	"text/tabwriter"
 	"time"
 )
 
+const DUMP_DATE_FORMAT = "2006-01-02 15:04"
+
 var List = cli.Command{
 	Name:    "list",
 	Aliases: []string{"ls"},
 	Usage:   "List available dumps",
-	Flags:   append([]cli.Flag{common.FORCE_FLAG}, common.AUTH_AND_TLS_FLAGS...),
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the dumps as JSON",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
 	Action: func(c *cli.Context) error {
 
 		dumps := fetchDumpList(c)
 		fmt.Fprintln(os.Stderr, "Done")
-		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(dumps))
+		if c.Bool("json") {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(dumps))
+		} else {
+			printDumpTable(os.Stdout, dumps.Dumps)
+		}
 
 		return nil
 	},
 }
 
+// printDumpTable lists the dumps newest first with the total size at the end
+func printDumpTable(out io.Writer, dumps []DumpEntry) {
+	if len(dumps) == 0 {
+		fmt.Fprintln(os.Stderr, "No dumps found")
+		return
+	}
+
+	var total int64
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, strings.Join([]string{"NAME", "DATE", "XP VERSION", "SIZE"}, "\t"))
+	for _, dump := range sortDumpsByDate(dumps) {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", dump.Name, dump.Timestamp.Local().Format(DUMP_DATE_FORMAT), dump.XpVersion, formatBytes(dump.Size))
+		total += dump.Size
+	}
+	fmt.Fprintf(writer, "Total\t\t\t%s\n", formatBytes(total))
+	writer.Flush()
+}
+
 func fetchDumpList(c *cli.Context) *DumpList {
 	req := common.CreateRequest(c, "GET", "system/dump", nil)
 	resp := common.SendRequest(c, req, "Loading dumps")
@@ -3,6 +3,7 @@ package dump
 import (
 	"bytes"
 	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/commands/storage"
 	"cli-enonic/internal/app/util"
 	"encoding/json"
 	"fmt"
@@ -17,7 +18,7 @@ import (
 var Load = cli.Command{
 	Name:  "load",
 	Usage: "Import data from a dump.",
-	Flags: append([]cli.Flag{
+	Flags: append(append([]cli.Flag{
 		cli.StringFlag{
 			Name:  "d",
 			Usage: "Dump name",
@@ -30,16 +31,27 @@ var Load = cli.Command{
 			Name:  "archive",
 			Usage: "Load dump from archive. Only effective in compat mode (XP 7).",
 		},
+		cli.StringFlag{
+			Name:  "upload",
+			Usage: "Upload this local dump archive or s3://bucket/key before loading it, encrypted and compressed archives are decoded first",
+		},
+		storage.PROFILE_FLAG,
 		common.FORCE_FLAG,
-	}, append(common.AUTH_AND_TLS_FLAGS, common.COMPAT_FLAG)...),
+	}, append(REPO_FILTER_FLAGS, DECODE_FLAGS...)...), append(common.AUTH_AND_TLS_FLAGS, common.COMPAT_FLAG)...),
 	Action: func(c *cli.Context) error {
 
 		util.Fatal(common.ValidateCompatFlag(c), "Invalid argument")
+		util.Fatal(validateRepoFilterFlags(c), "Invalid argument")
 
 		force := common.IsForceMode(c)
-		if force || util.PromptBool("WARNING: This will delete all existing repositories that also present in the system-dump. Continue", false) {
+		if force || util.PromptBool(loadWarning(c), false) {
 
-			name := ensureNameFlag(c, false, force)
+			var name string
+			if archive := c.String("upload"); archive != "" {
+				name = uploadDumpFile(c, archive, force).Name
+			} else {
+				name = ensureNameFlag(c, false, force)
+			}
 
 			req := createLoadRequest(c, name)
 			var result LoadDumpResponse
@@ -69,6 +81,19 @@ var Load = cli.Command{
 	},
 }
 
+func loadWarning(c *cli.Context) string {
+	var target string
+	if included := c.StringSlice("repo"); len(included) > 0 {
+		target = fmt.Sprintf("repositories %s if they are", strings.Join(included, ", "))
+	} else {
+		target = "all existing repositories that are"
+		if excluded := c.StringSlice("exclude-repo"); len(excluded) > 0 {
+			target = fmt.Sprintf("all existing repositories except %s that are", strings.Join(excluded, ", "))
+		}
+	}
+	return fmt.Sprintf("WARNING: This will delete %s also present in the system-dump. Continue", target)
+}
+
 func createLoadRequest(c *cli.Context, name string) *http.Request {
 	body := new(bytes.Buffer)
 	json.NewEncoder(body).Encode(buildLoadParams(c, name))
@@ -90,6 +115,7 @@ func buildLoadParams(c *cli.Context, name string) map[string]interface{} {
 	if upgrade := c.Bool("upgrade"); upgrade {
 		params["upgrade"] = upgrade
 	}
+	addRepoFilterParams(c, params)
 	return params
 }
 
@@ -0,0 +1,297 @@
+package dump
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/commands/remote"
+	"cli-enonic/internal/app/commands/sandbox"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var Migrate = cli.Command{
+	Name:  "migrate",
+	Usage: "Create a dump on one XP instance and load it on another one or into a local sandbox.",
+	Flags: append(append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "from",
+			Usage: "Remote to dump in the following format: [scheme]://[user:password]@[host]:[port]",
+		},
+		cli.StringFlag{
+			Name:  "to",
+			Usage: "Remote in the same format or name of a local sandbox to load the dump into",
+		},
+		cli.StringFlag{
+			Name:  "d",
+			Usage: "Dump name, defaults to migrate_<date>_<time>",
+		},
+		cli.BoolFlag{
+			Name:  "skip-versions",
+			Usage: "Don't dump version-history, only current versions included.",
+		},
+		cli.StringFlag{
+			Name:  "max-version-age",
+			Usage: "Max age of versions to include, in days, in addition to current version.",
+		},
+		cli.StringFlag{
+			Name:  "max-versions",
+			Usage: "Max number of versions to dump in addition to current version.",
+		},
+		cli.BoolFlag{
+			Name:  "upgrade",
+			Usage: "Upgrade the dump on the target if necessary (default is false)",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "Keep the transferred dump archive in this file",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the results as JSON",
+		},
+		common.FORCE_FLAG,
+	}, REPO_FILTER_FLAGS...), append(common.AUTH_AND_TLS_FLAGS, common.COMPAT_FLAG)...),
+	Action: func(c *cli.Context) error {
+
+		util.Fatal(common.ValidateCompatFlag(c), "Invalid argument")
+		util.Fatal(validateRepoFilterFlags(c), "Invalid argument")
+		force := common.IsForceMode(c)
+
+		from := remote.EnsureRemoteFlag(c, "from", force)
+		targetSandbox, to := ensureMigrateTarget(c, force)
+
+		if c.String("d") == "" {
+			c.Set("d", "migrate_"+time.Now().Format("20060102_150405"))
+		}
+
+		remote.UseRemote(from)
+		name, _ := normalizeName(ensureNameFlag(c, true, force))
+		result := MigrateResult{Name: name}
+		ensureMigrateTargetFree(c, name, targetSandbox, to, from)
+
+		fmt.Fprintf(os.Stderr, "Creating dump \"%s\" on %s\n", name, from.Url.String())
+		status := runDumpTask(c, createNewRequest(c, name), "Creating dump", &result.Dump)
+		if status == nil || status.State != common.TASK_FINISHED {
+			exitMigrate(c, status, "Failed to dump repositories", result)
+		}
+
+		// the archive is removed before checking the result, util.Fatal exits without running deferred calls
+		archive, cleanup := migrateArchivePath(c, name)
+		err := transferMigrateDump(c, name, archive, targetSandbox, to)
+		cleanup()
+		util.Fatal(err, "Could not migrate dump:")
+
+		if targetSandbox != "" {
+			fmt.Fprintf(os.Stderr, "Copied dump \"%s\" to sandbox \"%s\"\n", name, targetSandbox)
+
+			if rData := common.ReadRuntimeData(); !common.VerifyRuntimeData(&rData) || rData.Running != targetSandbox {
+				fmt.Fprintf(os.Stderr, "Sandbox \"%s\" is not running, start it and run 'enonic dump load -d %s' to load the dump\n", targetSandbox, name)
+				printMigrateResult(c, result)
+				return nil
+			}
+			remote.UseRemote(sandboxRemote())
+		} else {
+			fmt.Fprintf(os.Stderr, "Uploaded dump \"%s\" to %s\n", name, to.Url.String())
+		}
+
+		if !force && !util.PromptBool(loadWarning(c), false) {
+			printMigrateResult(c, result)
+			return nil
+		}
+
+		result.Load = new(LoadDumpResponse)
+		status = runDumpTask(c, createLoadRequest(c, name), "Loading dump", result.Load)
+		if status == nil || status.State != common.TASK_FINISHED {
+			exitMigrate(c, status, "Failed to load dump", result)
+		}
+		fmt.Fprintf(os.Stderr, "Migrated %d repositories in %s\n", len(result.Load.Repositories), util.TimeFromNow(status.StartTime))
+		fmt.Fprintln(os.Stderr, common.RESTART_ALL_RUNNING_INSTANCES_MSG)
+
+		printMigrateResult(c, result)
+
+		return nil
+	},
+}
+
+// ensureMigrateTarget returns either the name of a local sandbox or the remote to load the dump into
+func ensureMigrateTarget(c *cli.Context, force bool) (string, *remote.RemoteData) {
+	if to := c.String("to"); sandbox.Exists(to) {
+		return to, nil
+	}
+	return "", remote.EnsureRemoteFlag(c, "to", force)
+}
+
+// ensureMigrateTargetFree exits before anything is dumped if the target already has a dump with the name
+func ensureMigrateTargetFree(c *cli.Context, name, targetSandbox string, to, from *remote.RemoteData) {
+	if targetSandbox != "" {
+		if dumpExistsLocally(getSandboxDumpDir(targetSandbox), name) {
+			fmt.Fprintf(os.Stderr, "Dump with name '%s' already exists in sandbox \"%s\".\n", name, targetSandbox)
+			os.Exit(1)
+		}
+		return
+	}
+	remote.UseRemote(to)
+	if existing := listExistingDumpNames(c); containsDumpName(existing, name) {
+		fmt.Fprintf(os.Stderr, "Dump with name '%s' already exists on %s.\n", name, to.Url.String())
+		os.Exit(1)
+	}
+	remote.UseRemote(from)
+}
+
+// transferMigrateDump downloads the dump from the current remote into the archive
+// and copies it into the target sandbox or uploads it to the target remote
+func transferMigrateDump(c *cli.Context, name, archive, targetSandbox string, to *remote.RemoteData) error {
+	checksum, err := downloadDump(c, name, archive)
+	if err != nil {
+		return errors.Wrap(err, "could not download dump")
+	}
+	if err = verifyChecksum(archive, checksum); err != nil {
+		return errors.Wrap(err, "downloaded dump is corrupted")
+	}
+
+	if targetSandbox != "" {
+		target := filepath.Join(getSandboxDumpDir(targetSandbox), name)
+		if err = common.ExtractArchive(archive, target); err != nil {
+			os.RemoveAll(target)
+			return errors.Wrap(err, "could not copy dump")
+		}
+		return nil
+	}
+
+	remote.UseRemote(to)
+	_, err = uploadDump(c, name, archive)
+	return errors.Wrap(err, "could not upload dump")
+}
+
+// sandboxRemote points to the management port of the local sandbox, which is not the env defined remote
+// when ENONIC_CLI_REMOTE_URL is set
+func sandboxRemote() *remote.RemoteData {
+	sandboxUrl, _ := remote.ParseMarshalledUrl(fmt.Sprintf("http://localhost:%d", common.MGMT_PORT))
+	return &remote.RemoteData{Url: sandboxUrl}
+}
+
+func migrateArchivePath(c *cli.Context, name string) (string, func()) {
+	if output := c.String("output"); output != "" {
+		return output, func() {}
+	}
+	dir, err := os.MkdirTemp("", "enonic-dump-")
+	util.Fatal(err, "Could not create temporary folder:")
+	return filepath.Join(dir, name+".zip"), func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func runDumpTask(c *cli.Context, req *http.Request, msg string, target interface{}) *common.TaskStatus {
+	if common.IsCompatMode(c) {
+		return common.RunTask(c, req, msg, target)
+	}
+	return common.RunTaskWithSpinner(c, req, msg, target)
+}
+
+func containsDumpName(names []string, name string) bool {
+	for _, existing := range names {
+		if normalized, _ := normalizeName(existing); strings.EqualFold(normalized, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func exitMigrate(c *cli.Context, status *common.TaskStatus, message string, result MigrateResult) {
+	if status != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", message, status.Progress.Info)
+	}
+	printMigrateResult(c, result)
+	os.Exit(1)
+}
+
+func printMigrateResult(c *cli.Context, result MigrateResult) {
+	if c.Bool("json") {
+		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+	} else {
+		printMigrateTable(os.Stdout, migrateRows(&result.Dump, result.Load))
+	}
+}
+
+// migrateRows joins the per branch results of dumping and loading a repository
+func migrateRows(dumped *NewDumpResponse, loaded *LoadDumpResponse) []MigrateRow {
+	rowsByKey := make(map[string]*MigrateRow)
+	var rows []*MigrateRow
+	getRow := func(repo, branch string) *MigrateRow {
+		key := repo + "\x00" + branch
+		if row, found := rowsByKey[key]; found {
+			return row
+		}
+		row := &MigrateRow{Repository: repo, Branch: branch, Loaded: -1}
+		rowsByKey[key] = row
+		rows = append(rows, row)
+		return row
+	}
+
+	for _, repo := range dumped.Repositories {
+		for _, branch := range repo.Branches {
+			row := getRow(repo.RepositoryId, branch.Branch)
+			row.Dumped = branch.Successful
+			row.Errors += len(branch.Errors)
+		}
+	}
+	if loaded != nil {
+		for _, repo := range loaded.Repositories {
+			for _, branch := range repo.Branches {
+				row := getRow(repo.Repository, branch.Branch)
+				row.Loaded = branch.Successful
+				row.Errors += len(branch.Errors)
+			}
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Repository != rows[j].Repository {
+			return rows[i].Repository < rows[j].Repository
+		}
+		return rows[i].Branch < rows[j].Branch
+	})
+	result := make([]MigrateRow, len(rows))
+	for i, row := range rows {
+		result[i] = *row
+	}
+	return result
+}
+
+func printMigrateTable(out io.Writer, rows []MigrateRow) {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, strings.Join([]string{"REPOSITORY", "BRANCH", "DUMPED", "LOADED", "ERRORS"}, "\t"))
+	for _, row := range rows {
+		loaded := "-"
+		if row.Loaded >= 0 {
+			loaded = fmt.Sprint(row.Loaded)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%d\n", row.Repository, row.Branch, row.Dumped, loaded, row.Errors)
+	}
+	writer.Flush()
+}
+
+type MigrateResult struct {
+	Name string            `json:"name"`
+	Dump NewDumpResponse   `json:"dump"`
+	Load *LoadDumpResponse `json:"load,omitempty"`
+}
+
+type MigrateRow struct {
+	Repository string
+	Branch     string
+	Dumped     int64
+	// -1 when the dump was not loaded
+	Loaded int64
+	Errors int
+}
@@ -0,0 +1,93 @@
+package dump
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const migrateDumpJson = `{"repositories":[
+	{"repositoryId":"system-repo","versions":10,"branches":[{"branch":"master","successful":8,"errors":[]}]},
+	{"repositoryId":"com.enonic.cms.default","versions":20,"branches":[
+		{"branch":"master","successful":5,"errors":[]},
+		{"branch":"draft","successful":7,"errors":[{"message":"broken"}]}
+	]}
+]}`
+
+const migrateLoadJson = `{"repositories":[
+	{"repository":"system-repo","versions":{"successful":10,"errors":[]},"branches":[{"branch":"master","successful":8,"errors":[]}]},
+	{"repository":"com.enonic.cms.default","versions":{"successful":20,"errors":[]},"branches":[
+		{"branch":"draft","successful":6,"errors":[{"message":"failed"}]}
+	]}
+]}`
+
+func parseMigrateResponses(t *testing.T) (*NewDumpResponse, *LoadDumpResponse) {
+	t.Helper()
+	var dumped NewDumpResponse
+	var loaded LoadDumpResponse
+	if err := json.Unmarshal([]byte(migrateDumpJson), &dumped); err != nil {
+		t.Fatalf("parse dump response: %v", err)
+	}
+	if err := json.Unmarshal([]byte(migrateLoadJson), &loaded); err != nil {
+		t.Fatalf("parse load response: %v", err)
+	}
+	return &dumped, &loaded
+}
+
+func TestMigrateRowsJoinsDumpAndLoad(t *testing.T) {
+	dumped, loaded := parseMigrateResponses(t)
+
+	rows := migrateRows(dumped, loaded)
+
+	expected := []MigrateRow{
+		{Repository: "com.enonic.cms.default", Branch: "draft", Dumped: 7, Loaded: 6, Errors: 2},
+		{Repository: "com.enonic.cms.default", Branch: "master", Dumped: 5, Loaded: -1, Errors: 0},
+		{Repository: "system-repo", Branch: "master", Dumped: 8, Loaded: 8, Errors: 0},
+	}
+	if len(rows) != len(expected) {
+		t.Fatalf("expected %d rows, got %d: %+v", len(expected), len(rows), rows)
+	}
+	for i := range expected {
+		if rows[i] != expected[i] {
+			t.Errorf("row %d = %+v, want %+v", i, rows[i], expected[i])
+		}
+	}
+}
+
+func TestPrintMigrateTableWithoutLoad(t *testing.T) {
+	dumped, _ := parseMigrateResponses(t)
+
+	out := new(bytes.Buffer)
+	printMigrateTable(out, migrateRows(dumped, nil))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header and 3 rows, got:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "REPOSITORY BRANCH DUMPED LOADED ERRORS" {
+		t.Errorf("unexpected header: %q", lines[0])
+	}
+	if fields := strings.Fields(lines[3]); strings.Join(fields, " ") != "system-repo master 8 - 0" {
+		t.Errorf("unexpected row: %q", lines[3])
+	}
+}
+
+func TestContainsDumpName(t *testing.T) {
+	names := []string{"first", "Second.zip"}
+
+	if !containsDumpName(names, "second") {
+		t.Error("expected archived dump to match case-insensitively")
+	}
+	if containsDumpName(names, "third") {
+		t.Error("expected unknown dump not to match")
+	}
+}
+
+func TestSandboxRemoteIgnoresEnvRemote(t *testing.T) {
+	t.Setenv("ENONIC_CLI_REMOTE_URL", "https://production.example.com:4848")
+
+	if got := sandboxRemote().Url.String(); got != "http://localhost:4848" {
+		t.Errorf("got %s, want the local management url", got)
+	}
+}
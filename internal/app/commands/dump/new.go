@@ -15,7 +15,7 @@ import (
 var Create = cli.Command{
 	Name:  "create",
 	Usage: "Export data from every repository.",
-	Flags: append([]cli.Flag{
+	Flags: append(append([]cli.Flag{
 		cli.StringFlag{
 			Name:  "d",
 			Usage: "Dump name.",
@@ -37,10 +37,11 @@ var Create = cli.Command{
 			Usage: "Archive created dump. Only effective in compat mode (XP 7).",
 		},
 		common.FORCE_FLAG,
-	}, append(common.AUTH_AND_TLS_FLAGS, common.COMPAT_FLAG)...),
+	}, REPO_FILTER_FLAGS...), append(common.AUTH_AND_TLS_FLAGS, common.COMPAT_FLAG)...),
 	Action: func(c *cli.Context) error {
 
 		util.Fatal(common.ValidateCompatFlag(c), "Invalid argument")
+		util.Fatal(validateRepoFilterFlags(c), "Invalid argument")
 
 		name := ensureNameFlag(c, true, common.IsForceMode(c))
 
@@ -93,6 +94,7 @@ func buildNewParams(c *cli.Context, name string) map[string]interface{} {
 	if maxVersions := c.String("max-versions"); maxVersions != "" {
 		params["maxVersions"] = maxVersions
 	}
+	addRepoFilterParams(c, params)
 	return params
 }
 
@@ -0,0 +1,202 @@
+package dump
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/senseyeio/duration"
+	"github.com/urfave/cli"
+)
+
+const PRUNE_KEEP = "keep"
+const PRUNE_DELETE = "delete"
+
+var byteSizeRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([KMGT]I?B|B)?$`)
+
+var Prune = cli.Command{
+	Name:  "prune",
+	Usage: "Delete dumps according to retention rules.",
+	Flags: append([]cli.Flag{
+		cli.IntFlag{
+			Name:  "keep-last",
+			Usage: "Always keep this number of the most recent dumps",
+		},
+		cli.StringFlag{
+			Name:  "older-than",
+			Usage: "Delete dumps older than this ISO-8601 based duration (PnDTnHnMn.nS), e.g. P30D",
+		},
+		cli.StringFlag{
+			Name:  "max-total-size",
+			Usage: "Delete the oldest dumps until the rest fit in this size, e.g. 50GB",
+		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Only show which dumps would be deleted",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		rules, err := parsePruneRules(c.Int("keep-last"), c.String("older-than"), c.String("max-total-size"))
+		util.Fatal(err, "Invalid argument:")
+
+		plan := planDumpPrune(fetchDumpList(c).Dumps, rules, time.Now())
+		printPrunePlan(os.Stdout, plan)
+
+		toDelete := make([]string, 0)
+		for _, entry := range plan {
+			if entry.Action == PRUNE_DELETE {
+				toDelete = append(toDelete, entry.Dump.Name)
+			}
+		}
+		if len(toDelete) == 0 {
+			fmt.Fprintln(os.Stderr, "Nothing to delete")
+			return nil
+		}
+		if c.Bool("dry-run") {
+			fmt.Fprintf(os.Stderr, "Dry run, %d dump(s) would be deleted\n", len(toDelete))
+			return nil
+		}
+		if !common.IsForceMode(c) && !util.PromptBool(fmt.Sprintf("Delete %d dump(s)", len(toDelete)), false) {
+			return nil
+		}
+
+		result := deleteDumps(c, toDelete)
+		printDeleteResult(os.Stderr, result)
+		if len(result.FailedDumps) > 0 {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func parsePruneRules(keepLast int, olderThan, maxTotalSize string) (*PruneRules, error) {
+	rules := &PruneRules{KeepLast: keepLast, MaxTotalSize: -1}
+	if keepLast < 0 {
+		return nil, errors.New("--keep-last can not be negative")
+	}
+	if olderThan != "" {
+		age, err := duration.ParseISO8601(olderThan)
+		if err != nil {
+			return nil, errors.Errorf("invalid --older-than '%s', should be ISO-8601 based duration (PnDTnHnMn.nS)", olderThan)
+		}
+		rules.OlderThan = &age
+	}
+	if maxTotalSize != "" {
+		size, err := parseByteSize(maxTotalSize)
+		if err != nil {
+			return nil, err
+		}
+		rules.MaxTotalSize = size
+	}
+	if rules.OlderThan == nil && rules.MaxTotalSize < 0 && keepLast == 0 {
+		return nil, errors.New("at least one of --keep-last, --older-than or --max-total-size is required")
+	}
+	return rules, nil
+}
+
+// parseByteSize reads sizes like 500MB or 1.5GiB, KB/MB/GB/TB are powers of 1000 and KiB/MiB/GiB/TiB of 1024
+func parseByteSize(text string) (int64, error) {
+	match := byteSizeRegex.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(text)))
+	if match == nil {
+		return 0, errors.Errorf("invalid size '%s', use a number with B, KB, MB, GB, TB or KiB, MiB, GiB, TiB", text)
+	}
+	value, _ := strconv.ParseFloat(match[1], 64)
+
+	unit := match[2]
+	base := 1000.0
+	if strings.HasSuffix(unit, "IB") {
+		base = 1024
+	}
+	multiplier := 1.0
+	if unit != "" && unit != "B" {
+		multiplier = base
+		for _, prefix := range "KMGT" {
+			if rune(unit[0]) == prefix {
+				break
+			}
+			multiplier *= base
+		}
+	}
+	return int64(value * multiplier), nil
+}
+
+// planDumpPrune decides which dumps to keep, newest first. The most recent keep-last dumps are always kept,
+// dumps older than the age are deleted and then the oldest remaining ones until the total size fits.
+// With keep-last as the only rule all the other dumps are deleted, like snapshot prune does.
+func planDumpPrune(dumps []DumpEntry, rules *PruneRules, now time.Time) []PrunePlanEntry {
+	sorted := sortDumpsByDate(dumps)
+	keepLastOnly := rules.OlderThan == nil && rules.MaxTotalSize < 0
+
+	plan := make([]PrunePlanEntry, len(sorted))
+	for i, dump := range sorted {
+		plan[i] = PrunePlanEntry{Dump: dump, Action: PRUNE_KEEP}
+		if i < rules.KeepLast {
+			plan[i].Reason = fmt.Sprintf("one of the last %d", rules.KeepLast)
+		} else if keepLastOnly {
+			plan[i].Action = PRUNE_DELETE
+			plan[i].Reason = fmt.Sprintf("not one of the last %d", rules.KeepLast)
+		} else if rules.OlderThan != nil && rules.OlderThan.Shift(dump.Timestamp).Before(now) {
+			plan[i].Action = PRUNE_DELETE
+			plan[i].Reason = "older than " + rules.OlderThan.String()
+		}
+	}
+
+	if rules.MaxTotalSize >= 0 {
+		var total int64
+		for _, entry := range plan {
+			if entry.Action == PRUNE_KEEP {
+				total += entry.Dump.Size
+			}
+		}
+		for i := len(plan) - 1; i >= rules.KeepLast && total > rules.MaxTotalSize; i-- {
+			if plan[i].Action == PRUNE_KEEP {
+				plan[i].Action = PRUNE_DELETE
+				plan[i].Reason = "total size above " + formatBytes(rules.MaxTotalSize)
+				total -= plan[i].Dump.Size
+			}
+		}
+	}
+	return plan
+}
+
+func sortDumpsByDate(dumps []DumpEntry) []DumpEntry {
+	sorted := make([]DumpEntry, len(dumps))
+	copy(sorted, dumps)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.After(sorted[j].Timestamp)
+	})
+	return sorted
+}
+
+func printPrunePlan(out io.Writer, plan []PrunePlanEntry) {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, strings.Join([]string{"ACTION", "NAME", "DATE", "SIZE", "REASON"}, "\t"))
+	for _, entry := range plan {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", entry.Action, entry.Dump.Name, entry.Dump.Timestamp.Local().Format(DUMP_DATE_FORMAT), formatBytes(entry.Dump.Size), entry.Reason)
+	}
+	writer.Flush()
+}
+
+type PruneRules struct {
+	KeepLast  int
+	OlderThan *duration.Duration
+	// -1 when there is no size limit
+	MaxTotalSize int64
+}
+
+type PrunePlanEntry struct {
+	Dump   DumpEntry
+	Action string
+	Reason string
+}
@@ -0,0 +1,130 @@
+package dump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+var pruneNow = time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+
+func pruneDumps() []DumpEntry {
+	day := 24 * time.Hour
+	return []DumpEntry{
+		{Name: "d10", Timestamp: pruneNow.Add(-10 * day), Size: 10},
+		{Name: "d1", Timestamp: pruneNow.Add(-1 * day), Size: 10},
+		{Name: "d40", Timestamp: pruneNow.Add(-40 * day), Size: 10},
+		{Name: "d5", Timestamp: pruneNow.Add(-5 * day), Size: 10},
+		{Name: "d60", Timestamp: pruneNow.Add(-60 * day), Size: 10},
+	}
+}
+
+func plannedActions(plan []PrunePlanEntry) string {
+	actions := make([]string, len(plan))
+	for i, entry := range plan {
+		actions[i] = entry.Dump.Name + "=" + entry.Action
+	}
+	return strings.Join(actions, " ")
+}
+
+func TestPlanDumpPruneOlderThan(t *testing.T) {
+	rules, err := parsePruneRules(0, "P30D", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := plannedActions(planDumpPrune(pruneDumps(), rules, pruneNow))
+	want := "d1=keep d5=keep d10=keep d40=delete d60=delete"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestPlanDumpPruneKeepLastWins(t *testing.T) {
+	rules, _ := parsePruneRules(4, "P3D", "")
+
+	got := plannedActions(planDumpPrune(pruneDumps(), rules, pruneNow))
+	want := "d1=keep d5=keep d10=keep d40=keep d60=delete"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestPlanDumpPruneKeepLastOnly(t *testing.T) {
+	rules, err := parsePruneRules(2, "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	plan := planDumpPrune(pruneDumps(), rules, pruneNow)
+	got := plannedActions(plan)
+	want := "d1=keep d5=keep d10=delete d40=delete d60=delete"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if plan[2].Reason != "not one of the last 2" {
+		t.Errorf("unexpected reason: %q", plan[2].Reason)
+	}
+}
+
+func TestPlanDumpPruneMaxTotalSize(t *testing.T) {
+	rules, _ := parsePruneRules(1, "P50D", "25B")
+
+	plan := planDumpPrune(pruneDumps(), rules, pruneNow)
+	got := plannedActions(plan)
+	want := "d1=keep d5=keep d10=delete d40=delete d60=delete"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if !strings.HasPrefix(plan[2].Reason, "total size above") || !strings.HasPrefix(plan[4].Reason, "older than") {
+		t.Errorf("unexpected reasons: %q, %q", plan[2].Reason, plan[4].Reason)
+	}
+}
+
+func TestParsePruneRulesRequiresRule(t *testing.T) {
+	if _, err := parsePruneRules(0, "", ""); err == nil {
+		t.Error("expected error without rules")
+	}
+	if _, err := parsePruneRules(0, "30 days", ""); err == nil {
+		t.Error("expected error for invalid duration")
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]int64{
+		"100":   100,
+		"100B":  100,
+		"2KB":   2000,
+		"1.5GB": 1500000000,
+		"50 gb": 50000000000,
+		"1KiB":  1024,
+		"2MiB":  2 * 1024 * 1024,
+		"1TiB":  1024 * 1024 * 1024 * 1024,
+	}
+	for text, want := range cases {
+		got, err := parseByteSize(text)
+		if err != nil || got != want {
+			t.Errorf("parseByteSize(%q) = %d, %v, want %d", text, got, err, want)
+		}
+	}
+	if _, err := parseByteSize("lots"); err == nil {
+		t.Error("expected error for invalid size")
+	}
+}
+
+func TestPrintDumpTableSortsByDate(t *testing.T) {
+	out := new(bytes.Buffer)
+	printDumpTable(out, pruneDumps())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("expected header, 5 dumps and total, got:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[1], "d1 ") || !strings.HasPrefix(lines[5], "d60 ") {
+		t.Errorf("expected newest dump first:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[6], "Total") || !strings.HasSuffix(lines[6], "50 B") {
+		t.Errorf("unexpected total line: %q", lines[6])
+	}
+}
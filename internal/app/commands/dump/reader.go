@@ -0,0 +1,231 @@
+package dump
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"cli-enonic/internal/app/commands/common"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Layout of a file dump written by XP:
+//
+//	dump.json                              dump info with versions and the result of the dump
+//	meta/<repository>/<branch>/meta.tar.gz one json entry per node in the branch
+//	meta/<repository>/versions.tar.gz      one json entry per node with all its versions
+//	meta/<repository>/commits.tar.gz       commits of the repository
+//	data/<blob store segments>             blobs, the ones of binary segments hold attachments
+const DUMP_INFO_FILE = "dump.json"
+const DUMP_META_DIR = "meta"
+const DUMP_DATA_DIR = "data"
+const DUMP_BRANCH_FILE = "meta.tar.gz"
+const DUMP_VERSIONS_FILE = "versions.tar.gz"
+const DUMP_COMMITS_FILE = "commits.tar.gz"
+const DUMP_BINARY_SEGMENT = "binary"
+
+type dumpFile struct {
+	Path string
+	Size int64
+}
+
+// dumpReader gives access to a dump regardless of it being a folder or a zip archive, paths use forward slashes
+type dumpReader interface {
+	Files() []dumpFile
+	Open(name string) (io.ReadCloser, error)
+	Close() error
+}
+
+func openDumpReader(dumpPath string) (dumpReader, error) {
+	info, err := os.Stat(dumpPath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return newDirDumpReader(dumpPath)
+	}
+	return newZipDumpReader(dumpPath)
+}
+
+type dirDumpReader struct {
+	root  string
+	files []dumpFile
+}
+
+func newDirDumpReader(root string) (*dirDumpReader, error) {
+	reader := &dirDumpReader{root: root}
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		reader.files = append(reader.files, dumpFile{filepath.ToSlash(rel), info.Size()})
+		return nil
+	})
+	return reader, err
+}
+
+func (r *dirDumpReader) Files() []dumpFile {
+	return r.files
+}
+
+func (r *dirDumpReader) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(r.root, filepath.FromSlash(name)))
+}
+
+func (r *dirDumpReader) Close() error {
+	return nil
+}
+
+type zipDumpReader struct {
+	archive *zip.ReadCloser
+	entries map[string]*zip.File
+	files   []dumpFile
+}
+
+func newZipDumpReader(archive string) (*zipDumpReader, error) {
+	zipReader, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+	reader := &zipDumpReader{archive: zipReader, entries: make(map[string]*zip.File)}
+	root := common.FindArchiveRoot(zipReader.File)
+	for _, f := range zipReader.File {
+		name := strings.TrimPrefix(f.Name, root)
+		if name == "" || f.FileInfo().IsDir() {
+			continue
+		}
+		reader.entries[name] = f
+		reader.files = append(reader.files, dumpFile{name, int64(f.UncompressedSize64)})
+	}
+	return reader, nil
+}
+
+func (r *zipDumpReader) Files() []dumpFile {
+	return r.files
+}
+
+func (r *zipDumpReader) Open(name string) (io.ReadCloser, error) {
+	if f, found := r.entries[name]; found {
+		return f.Open()
+	}
+	return nil, os.ErrNotExist
+}
+
+func (r *zipDumpReader) Close() error {
+	return r.archive.Close()
+}
+
+func readDumpInfo(reader dumpReader) (*DumpInfo, error) {
+	file, err := reader.Open(DUMP_INFO_FILE)
+	if err != nil {
+		return nil, errors.Errorf("%s not found, this is not a dump", DUMP_INFO_FILE)
+	}
+	defer file.Close()
+
+	var info DumpInfo
+	if err = json.NewDecoder(file).Decode(&info); err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s", DUMP_INFO_FILE)
+	}
+	return &info, nil
+}
+
+// listDumpBranches returns the branch files of every repository in the dump, keyed by repository and then branch
+func listDumpBranches(reader dumpReader) map[string]map[string]string {
+	repos := make(map[string]map[string]string)
+	for _, f := range reader.Files() {
+		parts := strings.Split(f.Path, "/")
+		if len(parts) < 3 || parts[0] != DUMP_META_DIR {
+			continue
+		}
+		branches, found := repos[parts[1]]
+		if !found {
+			branches = make(map[string]string)
+			repos[parts[1]] = branches
+		}
+		if len(parts) == 4 && parts[3] == DUMP_BRANCH_FILE {
+			branches[parts[2]] = f.Path
+		}
+	}
+	return repos
+}
+
+// readTarGzEntries calls fn with the content of every file in a gzipped tar from the dump
+func readTarGzEntries(reader dumpReader, name string, fn func(data []byte) error) error {
+	file, err := reader.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return errors.Wrapf(err, "could not read %s", name)
+	}
+	defer gz.Close()
+
+	tarReader := tar.NewReader(gz)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrapf(err, "could not read %s", name)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tarReader)
+		if err != nil {
+			return errors.Wrapf(err, "could not read %s", name)
+		}
+		if err = fn(data); err != nil {
+			return err
+		}
+	}
+}
+
+func readBranchEntries(reader dumpReader, name string, fn func(entry *DumpBranchEntry)) error {
+	return readTarGzEntries(reader, name, func(data []byte) error {
+		var entry DumpBranchEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return errors.Wrapf(err, "could not parse node entry in %s", name)
+		}
+		fn(&entry)
+		return nil
+	})
+}
+
+type DumpInfo struct {
+	XpVersion        string           `json:"xpVersion"`
+	Timestamp        time.Time        `json:"timestamp"`
+	ModelVersion     string           `json:"modelVersion"`
+	SystemDumpResult *NewDumpResponse `json:"systemDumpResult,omitempty"`
+}
+
+type DumpBranchEntry struct {
+	NodeId    string `json:"nodeId"`
+	NodePath  string `json:"nodePath"`
+	Timestamp string `json:"timestamp"`
+	Version   struct {
+		Id                   string   `json:"id"`
+		NodeBlobKey          string   `json:"nodeBlobKey"`
+		IndexConfigBlobKey   string   `json:"indexConfigBlobKey"`
+		AccessControlBlobKey string   `json:"accessControlBlobKey"`
+		BinaryBlobKeys       []string `json:"binaryBlobKeys"`
+	} `json:"version"`
+}
+
+type DumpVersionsEntry struct {
+	NodeId   string            `json:"nodeId"`
+	Versions []json.RawMessage `json:"versions"`
+}
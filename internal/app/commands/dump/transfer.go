@@ -0,0 +1,94 @@
+package dump
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/commands/sandbox"
+	"fmt"
+	"github.com/urfave/cli"
+	"gopkg.in/cheggaaa/pb.v1"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+const DOWNLOAD_URL = "system/dump/download"
+const UPLOAD_URL = "system/dump/upload"
+const UPLOAD_COMPLETE_URL = "system/dump/upload/complete"
+
+func getSandboxDumpDir(sandboxName string) string {
+	return filepath.Join(sandbox.GetSandboxHomePath(sandboxName), "data", "dump")
+}
+
+// downloadDump fetches the dump archive into the target file, continuing a previous partial download if there is one.
+// Returns the checksum announced by the server or an empty string if it did not send any.
+func downloadDump(c *cli.Context, name, target string) (string, error) {
+	return common.DownloadArchive(c, DOWNLOAD_URL+"?name="+url.QueryEscape(name), target, "Downloading dump")
+}
+
+// streamDump fetches the dump archive into the writer without keeping any of it on disk.
+// Returns the checksum announced by the server and the checksum of the received archive.
+func streamDump(c *cli.Context, name string, w io.Writer) (string, string, error) {
+	return common.StreamArchive(c, DOWNLOAD_URL+"?name="+url.QueryEscape(name), w, "Downloading dump")
+}
+
+// uploadDump sends the archive in chunks, asking the server first how much of it it already has.
+// Encrypted and compressed archives are decoded while they are sent, so the plain archive never touches the disk.
+func uploadDump(c *cli.Context, name, archive string) (*common.UploadResult, error) {
+	encoded, err := isEncodedDumpArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+	if !encoded {
+		return common.UploadArchive(c, UPLOAD_URL, UPLOAD_COMPLETE_URL, name, archive, "Uploading dump")
+	}
+
+	identities := identitiesOnce(c)
+	return common.UploadStream(c, UPLOAD_URL, UPLOAD_COMPLETE_URL, name, "Uploading dump", 0, func() (io.ReadCloser, error) {
+		return openDumpFile(archive, identities)
+	})
+}
+
+func verifyChecksum(path, expected string) error {
+	if expected == "" {
+		fmt.Fprintln(os.Stderr, "Server did not send a checksum, skipping verification")
+		return nil
+	}
+	return common.VerifyChecksum(path, expected)
+}
+
+func verifyStreamChecksum(actual, expected string) error {
+	if expected == "" {
+		fmt.Fprintln(os.Stderr, "Server did not send a checksum, skipping verification")
+		return nil
+	}
+	return common.CompareChecksum(actual, expected)
+}
+
+func dumpExistsLocally(dumpDir, name string) bool {
+	normalized, _ := normalizeName(name)
+	for _, candidate := range []string{normalized, normalized + ".zip"} {
+		if _, err := os.Stat(filepath.Join(dumpDir, candidate)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func listLocalDumpNames(dumpDir string) []string {
+	entries, err := os.ReadDir(dumpDir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) == ".zip" {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+func formatBytes(size int64) string {
+	return pb.Format(size).To(pb.U_BYTES).String()
+}
@@ -0,0 +1,177 @@
+package dump
+
+import (
+	"bytes"
+	"cli-enonic/internal/app/commands/common"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/urfave/cli"
+)
+
+func useTestRemote(t *testing.T, url string) {
+	home := t.TempDir()
+	os.MkdirAll(filepath.Join(home, ".enonic"), 0755)
+	t.Setenv("ENONIC_CLI_HOME_PATH", home)
+	t.Setenv("ENONIC_CLI_REMOTE_URL", url)
+	t.Setenv("ENONIC_CLI_REMOTE_USER", "su")
+	t.Setenv("ENONIC_CLI_REMOTE_PASS", "password")
+}
+
+func TestDownloadDumpResumesPartialFile(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+	sum := sha256.Sum256(content)
+
+	var gotRange string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+DOWNLOAD_URL || r.URL.Query().Get("name") != "mydump" {
+			http.NotFound(w, r)
+			return
+		}
+		gotRange = r.Header.Get("Range")
+		var offset int
+		fmt.Sscanf(gotRange, "bytes=%d-", &offset)
+		w.Header().Set(common.DIGEST_HEADER, common.DIGEST_SHA256+base64.StdEncoding.EncodeToString(sum[:]))
+		w.Header().Set("Content-Length", fmt.Sprint(len(content)-offset))
+		if offset > 0 {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		w.Write(content[offset:])
+	}))
+	defer server.Close()
+	useTestRemote(t, server.URL)
+
+	target := filepath.Join(t.TempDir(), "mydump.zip")
+	os.WriteFile(target+common.PART_FILE_EXT, content[:300], 0644)
+	os.WriteFile(target+common.PART_FILE_EXT+common.PART_INFO_EXT, []byte(fmt.Sprintf(`{"url":"%s?name=mydump","size":%d}`, DOWNLOAD_URL, len(content))), 0644)
+
+	c := cli.NewContext(nil, flag.NewFlagSet("test", 0), nil)
+	checksum, err := downloadDump(c, "mydump", target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotRange != "bytes=300-" {
+		t.Errorf("expected download to resume at 300, got range %q", gotRange)
+	}
+	if err = verifyChecksum(target, checksum); err != nil {
+		t.Errorf("unexpected checksum error: %v", err)
+	}
+	if _, err = os.Stat(target + common.PART_FILE_EXT); !os.IsNotExist(err) {
+		t.Error("expected partial file to be renamed")
+	}
+}
+
+func TestVerifyChecksumMismatch(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "dump.zip")
+	os.WriteFile(file, []byte("dump"), 0644)
+
+	if err := verifyChecksum(file, strings.Repeat("0", 64)); err == nil {
+		t.Error("expected checksum mismatch error")
+	}
+}
+
+func TestDownloadEncodedDumpKeepsNoPlainArchive(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+	sum := sha256.Sum256(content)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(common.DIGEST_HEADER, common.DIGEST_SHA256+base64.StdEncoding.EncodeToString(sum[:]))
+		w.Write(content)
+	}))
+	defer server.Close()
+	useTestRemote(t, server.URL)
+
+	identity, _ := age.GenerateX25519Identity()
+	dir := t.TempDir()
+	output := filepath.Join(dir, "mydump.zip.zst.age")
+	c := cli.NewContext(nil, flag.NewFlagSet("test", 0), nil)
+	if err := downloadEncodedDump(c, "mydump", output, true, []age.Recipient{identity.Recipient()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected only the encoded dump in the folder, got %v", entries)
+	}
+	encoded, _ := os.ReadFile(output)
+	var decoded bytes.Buffer
+	err := decodeDumpArchive(bytes.NewReader(encoded), &decoded, func() ([]age.Identity, error) {
+		return []age.Identity{identity}, nil
+	})
+	if err != nil || !bytes.Equal(decoded.Bytes(), content) {
+		t.Errorf("unexpected decoded dump %q, %v", decoded.Bytes(), err)
+	}
+}
+
+func TestDownloadEncodedDumpChecksumMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(common.DIGEST_HEADER, common.DIGEST_SHA256+base64.StdEncoding.EncodeToString(make([]byte, 32)))
+		w.Write([]byte("dump"))
+	}))
+	defer server.Close()
+	useTestRemote(t, server.URL)
+
+	dir := t.TempDir()
+	c := cli.NewContext(nil, flag.NewFlagSet("test", 0), nil)
+	if err := downloadEncodedDump(c, "mydump", filepath.Join(dir, "mydump.zip.zst"), true, nil); err == nil {
+		t.Error("expected checksum mismatch error")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected nothing to be kept, got %v", entries)
+	}
+}
+
+func TestUploadEncodedDumpResumes(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+	sum := sha256.Sum256(content)
+	received := append([]byte{}, content[:300]...)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/"+UPLOAD_URL && r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(common.UploadResult{Name: "mydump", Size: int64(len(received))})
+		case r.URL.Path == "/"+UPLOAD_URL:
+			if r.URL.Query().Get("offset") != fmt.Sprint(len(received)) {
+				http.Error(w, "unexpected offset", http.StatusBadRequest)
+				return
+			}
+			chunk, _ := io.ReadAll(r.Body)
+			received = append(received, chunk...)
+			json.NewEncoder(w).Encode(common.UploadResult{Name: "mydump", Size: int64(len(received))})
+		case r.URL.Path == "/"+UPLOAD_COMPLETE_URL:
+			got := sha256.Sum256(received)
+			json.NewEncoder(w).Encode(common.UploadResult{Name: "mydump", Size: int64(len(received)), Sha256: hex.EncodeToString(got[:])})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	useTestRemote(t, server.URL)
+
+	archive := filepath.Join(t.TempDir(), "mydump.zip.zst")
+	var encoded bytes.Buffer
+	encodeDumpArchive(bytes.NewReader(content), &encoded, true, nil)
+	os.WriteFile(archive, encoded.Bytes(), 0644)
+
+	c := cli.NewContext(nil, flag.NewFlagSet("test", 0), nil)
+	result, err := uploadDump(c, "mydump", archive)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(received, content) || result.Sha256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("server did not receive the plain archive: %q", received)
+	}
+}
@@ -0,0 +1,107 @@
+package dump
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/commands/sandbox"
+	"cli-enonic/internal/app/commands/storage"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+var Upload = cli.Command{
+	Name:      "upload",
+	Usage:     "Upload a dump zip archive, continuing an interrupted upload if there is one.",
+	ArgsUsage: "<file.zip|s3://bucket/key>",
+	Flags: append(append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "d",
+			Usage: "Dump name, defaults to the archive name",
+		},
+		common.SANDBOX_FLAG,
+		storage.PROFILE_FLAG,
+		common.FORCE_FLAG,
+	}, DECODE_FLAGS...), common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		archive := c.Args().First()
+		if archive == "" {
+			fmt.Fprintln(os.Stderr, "Dump archive to upload is required")
+			os.Exit(1)
+		}
+
+		if sandboxName := sandbox.EnsureSandboxFlag(c); sandboxName != "" {
+			ensureDefaultDumpName(c, archive)
+			name, _ := normalizeName(c.String("d"))
+			dumpDir := getSandboxDumpDir(sandboxName)
+			if dumpExistsLocally(dumpDir, name) {
+				fmt.Fprintf(os.Stderr, "Dump with name '%s' already exists in sandbox \"%s\".\n", name, sandboxName)
+				os.Exit(1)
+			}
+
+			// extracting needs the plain zip as a file, the decoded one is removed before any exit
+			zipFile, cleanup := prepareDumpFile(c, archive)
+			target := filepath.Join(dumpDir, name)
+			err := common.ExtractArchive(zipFile, target)
+			cleanup()
+			if err != nil {
+				os.RemoveAll(target)
+				util.Fatal(err, "Could not copy dump:")
+			}
+			fmt.Fprintf(os.Stderr, "Copied dump \"%s\" to sandbox \"%s\"\n", name, sandboxName)
+			return nil
+		}
+
+		result := uploadDumpFile(c, archive, common.IsForceMode(c))
+
+		fmt.Fprintf(os.Stderr, "Uploaded dump \"%s\" (%s), checksum verified\n", result.Name, formatBytes(result.Size))
+		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+
+		return nil
+	},
+}
+
+func ensureDefaultDumpName(c *cli.Context, archive string) {
+	if c.String("d") == "" {
+		c.Set("d", strings.TrimSuffix(filepath.Base(decodedFileName(archive)), ".zip"))
+	}
+}
+
+// fetchDumpArchive checks the archive, defaults the dump name to its file name
+// and returns the local file of it with a function removing the file if it was fetched from a storage
+func fetchDumpArchive(c *cli.Context, archive string) (string, func()) {
+	ensureDefaultDumpName(c, archive)
+	localFile, removeLocalFile := storage.FetchFile(c, archive)
+	if info, err := os.Stat(localFile); err != nil || info.IsDir() {
+		fmt.Fprintf(os.Stderr, "Dump archive '%s' can not be found\n", archive)
+		os.Exit(1)
+	}
+	return localFile, removeLocalFile
+}
+
+// prepareDumpFile returns the plain zip of the archive, decrypted and decompressed into a temporary file if necessary
+func prepareDumpFile(c *cli.Context, archive string) (string, func()) {
+	localFile, removeLocalFile := fetchDumpArchive(c, archive)
+
+	zipFile, cleanup, err := decodeDumpFile(c, localFile)
+	util.Fatal(err, "Could not read dump archive:")
+	return zipFile, func() {
+		cleanup()
+		removeLocalFile()
+	}
+}
+
+func uploadDumpFile(c *cli.Context, archive string, force bool) *common.UploadResult {
+	localFile, removeLocalFile := fetchDumpArchive(c, archive)
+
+	name, _ := normalizeName(ensureNameFlag(c, true, force))
+
+	result, err := uploadDump(c, name, localFile)
+	util.Fatal(err, "Could not upload dump:")
+	removeLocalFile()
+	return result
+}
@@ -0,0 +1,331 @@
+package export
+
+import (
+	"bytes"
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v3"
+)
+
+const EXPORT_PROPERTIES_FILE = "export.properties"
+const EXPORT_XP_VERSION = "7.0.0"
+
+// node definition files, checked in this order
+var definitionFiles = []string{"node.json", "node.yaml", "node.yml"}
+
+var Build = cli.Command{
+	Name:      "build",
+	Usage:     "Build an export from a folder of JSON or YAML node definitions and their attachments.",
+	ArgsUsage: "<dir>",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "Export folder or zip archive to write, defaults to <dir name>.zip",
+		},
+		common.FORCE_FLAG,
+	},
+	Action: func(c *cli.Context) error {
+
+		source := c.Args().First()
+		if info, err := os.Stat(source); source == "" || err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "Folder with node definitions '%s' can not be found\n", source)
+			os.Exit(1)
+		}
+		output := c.String("output")
+		if output == "" {
+			output = exportNameOf(source) + ".zip"
+		}
+		common.EnsureOverwrite(c, output)
+
+		stats, err := buildExportTo(source, output)
+		util.Fatal(err, "Could not build export:")
+
+		fmt.Fprintf(os.Stderr, "Built export with %d nodes and %d binaries to '%s'\n", stats.Nodes, stats.Binaries, output)
+		return nil
+	},
+}
+
+// buildExportTo builds the export into the output folder, or into a zip archive when the output ends with .zip
+func buildExportTo(source, output string) (*ExportStats, error) {
+	zip := strings.EqualFold(filepath.Ext(output), ".zip")
+	var stats *ExportStats
+	err := replaceOutput(output, !zip, isExportDir, func(tmp string) error {
+		if !zip {
+			var err error
+			stats, err = buildExport(source, tmp)
+			return err
+		}
+
+		tmpDir, err := os.MkdirTemp("", "enonic-export-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+
+		if stats, err = buildExport(source, tmpDir); err != nil {
+			return err
+		}
+		return archiveExportDir(tmpDir, tmp)
+	})
+	return stats, err
+}
+
+// replaceOutput writes into a temporary file or folder next to the output and moves it into place only when
+// writing succeeded, so a failed run leaves the previous output as it was. An existing folder is only replaced
+// when it is empty or holds what is written to it, anything else is refused rather than deleted.
+func replaceOutput(output string, dir bool, isPreviousOutput func(path string) bool, write func(tmp string) error) error {
+	info, err := os.Stat(output)
+	exists := err == nil
+	if exists {
+		if info.IsDir() != dir {
+			return errors.Errorf("'%s' already exists and can not be replaced with the output", output)
+		}
+		if dir && !isEmptyDir(output) && !isPreviousOutput(output) {
+			return errors.Errorf("folder '%s' is not empty and does not hold a previous output, refusing to replace it", output)
+		}
+	}
+
+	parent := filepath.Dir(output)
+	if err = os.MkdirAll(parent, 0755); err != nil {
+		return err
+	}
+	prefix := "." + filepath.Base(output) + "-"
+	var tmp string
+	if dir {
+		if tmp, err = os.MkdirTemp(parent, prefix); err == nil {
+			err = os.Chmod(tmp, 0755)
+		}
+	} else {
+		var file *os.File
+		if file, err = os.CreateTemp(parent, prefix+"*"+common.PART_FILE_EXT); err == nil {
+			tmp = file.Name()
+			file.Close()
+			err = os.Chmod(tmp, 0644)
+		}
+	}
+	if err == nil {
+		err = write(tmp)
+	}
+	if err != nil {
+		if tmp != "" {
+			os.RemoveAll(tmp)
+		}
+		return err
+	}
+
+	if !dir || !exists {
+		if err = os.Rename(tmp, output); err != nil {
+			os.RemoveAll(tmp)
+		}
+		return err
+	}
+	// a folder can not be renamed over another one, so the previous output is moved aside until the new one is in place
+	previous := tmp + "-previous"
+	if err = os.Rename(output, previous); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err = os.Rename(tmp, output); err != nil {
+		os.Rename(previous, output)
+		os.RemoveAll(tmp)
+		return err
+	}
+	return os.RemoveAll(previous)
+}
+
+func isEmptyDir(path string) bool {
+	entries, err := os.ReadDir(path)
+	return err == nil && len(entries) == 0
+}
+
+// isExportDir tells if the folder is an export, which always has the export properties at the top
+func isExportDir(path string) bool {
+	info, err := os.Stat(filepath.Join(path, EXPORT_PROPERTIES_FILE))
+	return err == nil && !info.IsDir()
+}
+
+// isNodesDir tells if the folder holds node definitions, either of the top node or of every child folder
+func isNodesDir(path string) bool {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false
+	}
+	if findDefinitionFile(entries) != "" {
+		return true
+	}
+	nodes := 0
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if !entry.IsDir() {
+			return false
+		}
+		children, err := os.ReadDir(filepath.Join(path, entry.Name()))
+		if err != nil || findDefinitionFile(children) == "" {
+			return false
+		}
+		nodes++
+	}
+	return nodes > 0
+}
+
+func buildExport(source, target string) (*ExportStats, error) {
+	stats := &ExportStats{}
+	if err := buildNode(source, target, true, stats); err != nil {
+		return nil, err
+	}
+	properties := fmt.Sprintf("xp.version = %s\n", EXPORT_XP_VERSION)
+	return stats, os.WriteFile(filepath.Join(target, EXPORT_PROPERTIES_FILE), []byte(properties), 0644)
+}
+
+// buildNode writes the node of the folder and its children. The top folder may have no node definition
+// and only hold the exported nodes, every other folder is a node.
+func buildNode(source, target string, top bool, stats *ExportStats) error {
+	entries, err := os.ReadDir(source)
+	if err != nil {
+		return err
+	}
+
+	definitionFile := findDefinitionFile(entries)
+	if definitionFile == "" && !top {
+		return errors.Errorf("no node definition (%s) in '%s'", strings.Join(definitionFiles, ", "), source)
+	}
+
+	var children, attachments []string
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case strings.HasPrefix(name, "."), name == definitionFile:
+		case entry.IsDir():
+			if name == SYSTEM_DIR {
+				return errors.Errorf("folder name '%s' is reserved, rename '%s'", SYSTEM_DIR, filepath.Join(source, name))
+			}
+			children = append(children, name)
+		default:
+			attachments = append(attachments, name)
+		}
+	}
+
+	if definitionFile != "" {
+		if err = writeNode(source, definitionFile, attachments, filepath.Join(target, SYSTEM_DIR), stats); err != nil {
+			return err
+		}
+	} else if len(attachments) > 0 {
+		return errors.Errorf("files next to the exported nodes need a node definition in '%s': %s", source, strings.Join(attachments, ", "))
+	}
+
+	sort.Strings(children)
+	for _, child := range children {
+		if err = buildNode(filepath.Join(source, child), filepath.Join(target, child), false, stats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeNode(source, definitionFile string, attachments []string, systemDir string, stats *ExportStats) error {
+	definitionPath := filepath.Join(source, definitionFile)
+	def, err := readNodeDefinition(definitionPath)
+	if err != nil {
+		return errors.Wrapf(err, "invalid node definition '%s'", definitionPath)
+	}
+
+	refs, err := def.binaryReferences()
+	if err != nil {
+		return errors.Wrapf(err, "invalid node definition '%s'", definitionPath)
+	}
+	for _, ref := range refs {
+		if !containsName(attachments, ref) {
+			return errors.Errorf("binary '%s' referenced in '%s' can not be found", ref, definitionPath)
+		}
+	}
+
+	nodeXml, err := def.toNodeXml()
+	if err != nil {
+		return errors.Wrapf(err, "invalid node definition '%s'", definitionPath)
+	}
+	if err = os.MkdirAll(systemDir, 0755); err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(systemDir, NODE_XML_FILE), nodeXml, 0644); err != nil {
+		return err
+	}
+	if len(def.ManualChildOrder) > 0 {
+		order := strings.Join(def.ManualChildOrder, "\n") + "\n"
+		if err = os.WriteFile(filepath.Join(systemDir, MANUAL_CHILD_ORDER_FILE), []byte(order), 0644); err != nil {
+			return err
+		}
+	}
+	for _, attachment := range attachments {
+		if err = common.CopyFile(filepath.Join(source, attachment), filepath.Join(systemDir, BINARY_DIR, attachment)); err != nil {
+			return err
+		}
+	}
+
+	stats.Nodes++
+	stats.Binaries += len(attachments)
+	return nil
+}
+
+func findDefinitionFile(entries []os.DirEntry) string {
+	for _, name := range definitionFiles {
+		for _, entry := range entries {
+			if !entry.IsDir() && entry.Name() == name {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+func readNodeDefinition(path string) (*NodeDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var def NodeDefinition
+	if filepath.Ext(path) == ".json" {
+		// numbers are kept as written to tell longs from doubles
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&def)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err = decoder.Decode(&def); err == io.EOF {
+			// an empty file is a node with defaults
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+type ExportStats struct {
+	Nodes    int
+	Binaries int
+}
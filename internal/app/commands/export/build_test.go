@@ -0,0 +1,224 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func TestDataToProperties(t *testing.T) {
+	var data map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(`{
+		"displayName": "Hello",
+		"valid": true,
+		"count": 3,
+		"ratio": 0.5,
+		"tags": ["a", "b"],
+		"empty": null,
+		"data": {"text": "body"},
+		"modifiedTime": {"@type": "dateTime", "@value": "2024-01-01T00:00:00Z"}
+	}`))
+	decoder.UseNumber()
+	decoder.Decode(&data)
+
+	properties, err := dataToProperties(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := make([]string, 0)
+	for _, p := range properties {
+		got = append(got, p.kind()+" "+p.Name+"="+p.Value)
+	}
+	want := "long count=3, property-set data=, string displayName=Hello, string empty=, dateTime modifiedTime=2024-01-01T00:00:00Z, double ratio=0.5, string tags=a, string tags=b, boolean valid=true"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("got %s", strings.Join(got, ", "))
+	}
+	if !properties[3].IsNull {
+		t.Error("expected empty to be null")
+	}
+
+	if _, err = dataToProperties(map[string]interface{}{"x": map[string]interface{}{TYPE_KEY: "unknown"}}); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+}
+
+func TestPropertiesToDataRoundTrip(t *testing.T) {
+	data := map[string]interface{}{
+		"title": "Hello",
+		"count": int64(3),
+		"tags":  []interface{}{"a", "b"},
+		"data":  map[string]interface{}{"text": "body", "visible": false},
+		"ratio": map[string]interface{}{TYPE_KEY: TYPE_DOUBLE, VALUE_KEY: "1.0"},
+		"ref":   map[string]interface{}{TYPE_KEY: "reference", VALUE_KEY: nil},
+	}
+	properties, err := dataToProperties(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	back, _ := json.Marshal(propertiesToData(properties))
+	want, _ := json.Marshal(data)
+	if string(back) != string(want) {
+		t.Errorf("got %s, want %s", back, want)
+	}
+}
+
+func TestBuildAndUnpackExport(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "fixtures")
+	writeFile(t, filepath.Join(source, "site", "node.json"), `{
+		"nodeType": "content",
+		"manualChildOrder": ["about"],
+		"inheritPermissions": false,
+		"permissions": [{"principal": "role:system.everyone", "allow": ["READ"]}],
+		"data": {"displayName": "Site", "type": "portal:site"}
+	}`)
+	writeFile(t, filepath.Join(source, "site", "about", "node.yaml"), "nodeType: content\ndata:\n  displayName: About\n  attachment:\n    \"@type\": binaryReference\n    \"@value\": logo.png\n")
+	writeFile(t, filepath.Join(source, "site", "about", "logo.png"), "png")
+
+	export := filepath.Join(t.TempDir(), "fixtures")
+	stats, err := buildExport(source, export)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Nodes != 2 || stats.Binaries != 1 {
+		t.Errorf("got %d nodes and %d binaries", stats.Nodes, stats.Binaries)
+	}
+
+	nodeXml, _ := os.ReadFile(filepath.Join(export, "site", SYSTEM_DIR, NODE_XML_FILE))
+	for _, expected := range []string{`<node xmlns="urn:enonic:xp:export:1.0">`, `<string name="displayName">Site</string>`, `<principal key="role:system.everyone">`, `<inheritPermissions>false</inheritPermissions>`} {
+		if !strings.Contains(string(nodeXml), expected) {
+			t.Errorf("node.xml misses %s:\n%s", expected, nodeXml)
+		}
+	}
+	if binary, _ := os.ReadFile(filepath.Join(export, "site", "about", SYSTEM_DIR, BINARY_DIR, "logo.png")); string(binary) != "png" {
+		t.Errorf("unexpected binary %q", binary)
+	}
+	if order, _ := os.ReadFile(filepath.Join(export, "site", SYSTEM_DIR, MANUAL_CHILD_ORDER_FILE)); string(order) != "about\n" {
+		t.Errorf("unexpected child order %q", order)
+	}
+
+	unpacked := filepath.Join(t.TempDir(), "unpacked")
+	if _, err = unpackExport(export, unpacked, FORMAT_JSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	def, err := readNodeDefinition(filepath.Join(unpacked, "site", "about", "node.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if def.Data["displayName"] != "About" || def.NodeType != "content" {
+		t.Errorf("unexpected definition %+v", def)
+	}
+	if binary, _ := os.ReadFile(filepath.Join(unpacked, "site", "about", "logo.png")); string(binary) != "png" {
+		t.Errorf("unexpected attachment %q", binary)
+	}
+
+	rebuilt := filepath.Join(t.TempDir(), "rebuilt")
+	if _, err = buildExport(unpacked, rebuilt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rebuiltXml, _ := os.ReadFile(filepath.Join(rebuilt, "site", SYSTEM_DIR, NODE_XML_FILE))
+	if string(rebuiltXml) != string(nodeXml) {
+		t.Errorf("rebuilt node.xml differs:\n%s\n%s", nodeXml, rebuiltXml)
+	}
+}
+
+func TestBuildExportRejectsMissingBinary(t *testing.T) {
+	source := t.TempDir()
+	writeFile(t, filepath.Join(source, "file", "node.json"), `{"data": {"binary": {"@type": "binaryReference", "@value": "missing.pdf"}}}`)
+
+	if _, err := buildExport(source, t.TempDir()); err == nil || !strings.Contains(err.Error(), "missing.pdf") {
+		t.Errorf("expected missing binary error, got %v", err)
+	}
+}
+
+func TestBuildExportRejectsFolderWithoutDefinition(t *testing.T) {
+	source := t.TempDir()
+	writeFile(t, filepath.Join(source, "site", "node.json"), `{}`)
+	os.MkdirAll(filepath.Join(source, "site", "empty"), 0755)
+
+	if _, err := buildExport(source, t.TempDir()); err == nil || !strings.Contains(err.Error(), "no node definition") {
+		t.Errorf("expected missing definition error, got %v", err)
+	}
+}
+
+func TestBuildExportToKeepsOutputOnFailure(t *testing.T) {
+	source := t.TempDir()
+	writeFile(t, filepath.Join(source, "file", "node.json"), `{"data": {"binary": {"@type": "binaryReference", "@value": "missing.pdf"}}}`)
+	output := filepath.Join(t.TempDir(), "out.zip")
+	writeFile(t, output, "previous")
+
+	if _, err := buildExportTo(source, output); err == nil {
+		t.Fatal("expected an error")
+	}
+	if data, _ := os.ReadFile(output); string(data) != "previous" {
+		t.Errorf("previous output was changed to %q", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(output)); len(entries) != 1 {
+		t.Errorf("temporary output was left behind: %v", entries)
+	}
+}
+
+func TestBuildExportToReplacesPreviousExport(t *testing.T) {
+	source := t.TempDir()
+	writeFile(t, filepath.Join(source, "site", "node.json"), `{}`)
+	output := filepath.Join(t.TempDir(), "out")
+	writeFile(t, filepath.Join(output, EXPORT_PROPERTIES_FILE), "xp.version = 7.0.0\n")
+	writeFile(t, filepath.Join(output, "old", SYSTEM_DIR, NODE_XML_FILE), "<node/>")
+
+	if _, err := buildExportTo(source, output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, "old")); !os.IsNotExist(err) {
+		t.Error("previous export was not replaced")
+	}
+	if _, err := os.Stat(filepath.Join(output, "site", SYSTEM_DIR, NODE_XML_FILE)); err != nil {
+		t.Errorf("new export is missing: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(output)); len(entries) != 1 {
+		t.Errorf("temporary output was left behind: %v", entries)
+	}
+}
+
+func TestReplaceOutputRefusesForeignFolder(t *testing.T) {
+	output := t.TempDir()
+	writeFile(t, filepath.Join(output, "notes.txt"), "keep me")
+
+	written := false
+	err := replaceOutput(output, true, isExportDir, func(tmp string) error {
+		written = true
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "refusing") || written {
+		t.Errorf("expected the folder to be refused, got %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(output, "notes.txt")); string(data) != "keep me" {
+		t.Error("folder content was changed")
+	}
+}
+
+func TestIsNodesDir(t *testing.T) {
+	nodes := t.TempDir()
+	writeFile(t, filepath.Join(nodes, "site", "node.json"), `{}`)
+	other := t.TempDir()
+	writeFile(t, filepath.Join(other, "src", "main.go"), "package main")
+
+	if !isNodesDir(nodes) {
+		t.Error("expected a nodes folder")
+	}
+	if isNodesDir(other) || isNodesDir(t.TempDir()) {
+		t.Error("expected not to be a nodes folder")
+	}
+}
@@ -3,12 +3,16 @@ package export
 import (
 	"bytes"
 	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/commands/sandbox"
+	"cli-enonic/internal/app/commands/storage"
 	"cli-enonic/internal/app/util"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/pkg/errors"
 	"github.com/urfave/cli"
@@ -17,6 +21,10 @@ import (
 var Export = cli.Command{
 	Name:  "export",
 	Usage: "Export data from a given repository, branch and content path.",
+	Subcommands: []cli.Command{
+		Build,
+		Unpack,
+	},
 	Flags: append([]cli.Flag{
 		cli.StringFlag{
 			Name:  "t",
@@ -38,12 +46,31 @@ var Export = cli.Command{
 			Name:  "dry",
 			Usage: "Show the result without making actual changes.",
 		},
+		cli.StringFlag{
+			Name:  "download",
+			Usage: "File, folder or s3://bucket/prefix/ url to save the export to as a zip archive once it is done",
+		},
+		cli.StringFlag{
+			Name:  "report",
+			Usage: "File to write the export errors to as JSON",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the export result as JSON instead of a summary table",
+		},
+		common.SANDBOX_FLAG,
+		storage.PROFILE_FLAG,
 		common.FORCE_FLAG,
 	}, common.AUTH_AND_TLS_FLAGS...),
 	Action: func(c *cli.Context) error {
 
 		ensureNameFlag(c)
 		ensurePathFlag(c)
+		sandboxName := sandbox.EnsureSandboxFlag(c)
+		var location string
+		if c.String("download") != "" {
+			location = ensureDownloadLocation(c, c.String("t"), common.IsForceMode(c))
+		}
 
 		req := createNewRequest(c)
 		var result NewExportResponse
@@ -55,7 +82,21 @@ var Export = cli.Command{
 		case common.TASK_FAILED:
 			fmt.Fprintf(os.Stderr, "Export failed: %s\n", status.Progress.Info)
 		}
-		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+		if c.Bool("json") {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+		} else {
+			printExportSummary(os.Stdout, &result)
+		}
+
+		if location != "" && status.State == common.TASK_FINISHED && !result.DryRun {
+			saveExport(c, c.String("t"), location, sandboxName, status.StartTime)
+			fmt.Fprintf(os.Stderr, "Saved export \"%s\" to '%s'\n", c.String("t"), location)
+		}
+
+		writeReport(c.String("report"), "export", &ExportReport{Errors: result.errorMessages()})
+		if status.State != common.TASK_FINISHED || len(result.Errors) > 0 {
+			os.Exit(1)
+		}
 
 		return nil
 	},
@@ -88,6 +129,31 @@ type NewExportResponse struct {
 	} `json:"exportErrors"`
 }
 
+func (r *NewExportResponse) errorMessages() []string {
+	messages := make([]string, len(r.Errors))
+	for i, exportErr := range r.Errors {
+		messages[i] = exportErr.Message
+	}
+	return messages
+}
+
+// printExportSummary prints the number of exported nodes and binaries and of errors
+func printExportSummary(out io.Writer, result *NewExportResponse) {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, "RESULT\tCOUNT")
+	fmt.Fprintf(writer, "nodes\t%d\n", len(result.ExportedNodes))
+	fmt.Fprintf(writer, "binaries\t%d\n", len(result.ExportedBinaries))
+	fmt.Fprintf(writer, "failed\t%d\n", len(result.Errors))
+	writer.Flush()
+	if result.DryRun {
+		fmt.Fprintln(out, "Dry run, nothing was exported")
+	}
+}
+
+type ExportReport struct {
+	Errors []string `json:"errors"`
+}
+
 func ensureNameFlag(c *cli.Context) {
 	target := c.String("t")
 
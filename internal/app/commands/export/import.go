@@ -3,17 +3,25 @@ package export
 import (
 	"bytes"
 	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/commands/sandbox"
+	"cli-enonic/internal/app/commands/storage"
 	"cli-enonic/internal/app/util"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/pkg/errors"
 	"github.com/urfave/cli"
 )
 
+const CONFLICT_REPLACE = "replace"
+const CONFLICT_SKIP = "skip"
+const CONFLICT_FAIL = "fail"
+
 var xslParams map[string]string
 
 var Import = cli.Command{
@@ -48,26 +56,90 @@ var Import = cli.Command{
 			Name:  "dry",
 			Usage: "Show the result without making actual changes.",
 		},
+		cli.StringFlag{
+			Name:  "upload",
+			Usage: "Zip archive, folder or s3://bucket/key url of an export to upload before importing it, the export name defaults to its file name",
+		},
+		cli.BoolFlag{
+			Name:  "preview",
+			Usage: "Apply the XSL transformation to the export locally and show the changes of each node instead of importing, the export has to be given with --upload or be in the --sandbox",
+		},
+		cli.IntFlag{
+			Name:  "limit",
+			Usage: "Number of changed nodes to show in the preview, 0 to check all nodes",
+			Value: DEFAULT_PREVIEW_LIMIT,
+		},
+		cli.StringFlag{
+			Name:  "on-conflict",
+			Usage: "What to do with nodes that already exist: replace them, skip them or fail the import before it changes anything",
+			Value: CONFLICT_REPLACE,
+		},
+		cli.StringFlag{
+			Name:  "report",
+			Usage: "File to write the import errors, skipped and conflicting nodes to as JSON",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the import result as JSON instead of a summary table",
+		},
+		common.SANDBOX_FLAG,
+		storage.PROFILE_FLAG,
 		common.FORCE_FLAG,
 	}, common.AUTH_AND_TLS_FLAGS...),
 	Action: func(c *cli.Context) error {
 
+		upload := c.String("upload")
+		if upload != "" && c.String("t") == "" {
+			c.Set("t", exportNameOf(upload))
+		}
 		ensureNameFlag(c)
+		if c.Bool("preview") {
+			ensureXSLParamsFlagFormat(c)
+			previewImport(c, os.Stdout)
+			return nil
+		}
 		ensurePathFlag(c)
 		ensureXSLParamsFlagFormat(c)
+		onConflict := ensureOnConflictFlag(c)
+
+		if upload != "" {
+			uploadExport(c, c.String("t"), upload, sandbox.EnsureSandboxFlag(c), common.IsForceMode(c))
+		}
 
-		req := createLoadRequest(c)
+		if onConflict == CONFLICT_FAIL {
+			var check LoadDumpResponse
+			status := common.RunTask(c, createLoadRequest(c, true), "Checking for existing nodes", &check)
+			if status.State != common.TASK_FINISHED {
+				fmt.Fprintf(os.Stderr, "Import check failed: %s\n", status.Progress.Info)
+				os.Exit(1)
+			}
+			if len(check.UpdateNodes) > 0 {
+				report := &ImportReport{Conflicts: check.UpdateNodes}
+				writeReport(c.String("report"), "import", report)
+				printImportConflicts(os.Stderr, check.UpdateNodes)
+				os.Exit(1)
+			}
+		}
 
 		var result LoadDumpResponse
-		status := common.RunTask(c, req, "Importing data", &result)
+		status := common.RunTask(c, createLoadRequest(c, c.Bool("dry")), "Importing data", &result)
 
 		switch status.State {
 		case common.TASK_FINISHED:
-			fmt.Fprintf(os.Stderr, "Added %d nodes, updated %d nodes, imported %d binaries with %d errors in %s\n", len(result.AddedNodes), len(result.UpdateNodes), len(result.ImportedBinaries), len(result.ImportErrors), util.TimeFromNow(status.StartTime))
+			fmt.Fprintf(os.Stderr, "Added %d nodes, updated %d nodes, skipped %d nodes, imported %d binaries with %d errors in %s\n", len(result.AddedNodes), len(result.UpdateNodes), len(result.SkippedNodes), len(result.ImportedBinaries), len(result.ImportErrors), util.TimeFromNow(status.StartTime))
 		case common.TASK_FAILED:
 			fmt.Fprintf(os.Stderr, "Import failed: %s\n", status.Progress.Info)
 		}
-		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+		if c.Bool("json") {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+		} else {
+			printImportSummary(os.Stdout, &result)
+		}
+
+		writeReport(c.String("report"), "import", &ImportReport{Errors: result.ImportErrors, Skipped: result.SkippedNodes})
+		if status.State != common.TASK_FINISHED || len(result.ImportErrors) > 0 {
+			os.Exit(1)
+		}
 
 		return nil
 	},
@@ -98,7 +170,18 @@ func ensureXSLParamsFlagFormat(c *cli.Context) {
 	}
 }
 
-func createLoadRequest(c *cli.Context) *http.Request {
+func ensureOnConflictFlag(c *cli.Context) string {
+	onConflict := strings.ToLower(c.String("on-conflict"))
+	switch onConflict {
+	case CONFLICT_REPLACE, CONFLICT_SKIP, CONFLICT_FAIL:
+		return onConflict
+	}
+	fmt.Fprintf(os.Stderr, "Unknown --on-conflict value '%s', use %s, %s or %s\n", c.String("on-conflict"), CONFLICT_REPLACE, CONFLICT_SKIP, CONFLICT_FAIL)
+	os.Exit(1)
+	return ""
+}
+
+func createLoadRequest(c *cli.Context, dryRun bool) *http.Request {
 	body := new(bytes.Buffer)
 	params := map[string]interface{}{
 		"exportName":     c.String("t"),
@@ -115,7 +198,11 @@ func createLoadRequest(c *cli.Context) *http.Request {
 
 	params["importWithPermissions"] = !c.Bool("skip-permissions")
 
-	params["dryRun"] = c.Bool("dry")
+	params["dryRun"] = dryRun
+
+	if strings.ToLower(c.String("on-conflict")) == CONFLICT_SKIP {
+		params["onConflict"] = CONFLICT_SKIP
+	}
 
 	json.NewEncoder(body).Encode(params)
 
@@ -125,7 +212,44 @@ func createLoadRequest(c *cli.Context) *http.Request {
 type LoadDumpResponse struct {
 	AddedNodes       []string `json:"addedNodes"`
 	UpdateNodes      []string `json:"updateNodes"`
+	SkippedNodes     []string `json:"skippedNodes"`
 	ImportedBinaries []string `json:"importedBinaries"`
 	ImportErrors     []string `json:"importErrors"`
 	DryRun           bool     `json:"dryRun"`
 }
+
+// printImportSummary prints the number of nodes of each result
+func printImportSummary(out io.Writer, result *LoadDumpResponse) {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, "RESULT\tNODES")
+	fmt.Fprintf(writer, "added\t%d\n", len(result.AddedNodes))
+	fmt.Fprintf(writer, "updated\t%d\n", len(result.UpdateNodes))
+	fmt.Fprintf(writer, "skipped\t%d\n", len(result.SkippedNodes))
+	fmt.Fprintf(writer, "failed\t%d\n", len(result.ImportErrors))
+	writer.Flush()
+	if result.DryRun {
+		fmt.Fprintln(out, "Dry run, nothing was imported")
+	}
+}
+
+func printImportConflicts(out io.Writer, conflicts []string) {
+	fmt.Fprintf(out, "Import stopped, %d node(s) already exist:\n", len(conflicts))
+	for _, path := range conflicts {
+		fmt.Fprintf(out, "  %s\n", path)
+	}
+}
+
+// writeReport saves the import or export report to the file if one is given
+func writeReport(path, kind string, report interface{}) {
+	if path == "" {
+		return
+	}
+	util.Fatal(os.WriteFile(path, []byte(util.PrettyPrintJSON(report)), 0644), fmt.Sprintf("Could not write %s report:", kind))
+	fmt.Fprintf(os.Stderr, "Saved %s report to '%s'\n", kind, path)
+}
+
+type ImportReport struct {
+	Errors    []string `json:"errors"`
+	Skipped   []string `json:"skipped"`
+	Conflicts []string `json:"conflicts"`
+}
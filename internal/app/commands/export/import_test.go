@@ -0,0 +1,76 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrintImportSummary(t *testing.T) {
+	result := &LoadDumpResponse{
+		AddedNodes:   []string{"/site", "/site/about"},
+		UpdateNodes:  []string{"/site/contact"},
+		SkippedNodes: []string{"/site/news", "/site/blog", "/site/faq"},
+		ImportErrors: []string{"/site/broken: invalid node"},
+		DryRun:       true,
+	}
+	var out bytes.Buffer
+	printImportSummary(&out, result)
+
+	want := "RESULT    NODES\nadded     2\nupdated   1\nskipped   3\nfailed    1\nDry run, nothing was imported\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestPrintImportConflicts(t *testing.T) {
+	var out bytes.Buffer
+	printImportConflicts(&out, []string{"/site", "/site/about"})
+
+	if !strings.HasPrefix(out.String(), "Import stopped, 2 node(s) already exist:\n") || !strings.Contains(out.String(), "  /site/about\n") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	writeReport(path, "import", &ImportReport{Errors: []string{"/site/broken: invalid node"}, Skipped: []string{"/site"}})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	var report ImportReport
+	if err = json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("invalid report: %v", err)
+	}
+	if len(report.Errors) != 1 || len(report.Skipped) != 1 || len(report.Conflicts) != 0 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	// no file is written without a path
+	writeReport("", "import", &ImportReport{})
+}
+
+func TestPrintExportSummary(t *testing.T) {
+	var result NewExportResponse
+	json.Unmarshal([]byte(`{
+		"exportedNodes": ["/site", "/site/about", "/site/contact"],
+		"exportedBinaries": ["logo.png"],
+		"exportErrors": [{"message": "/site/broken: invalid node"}]
+	}`), &result)
+
+	var out bytes.Buffer
+	printExportSummary(&out, &result)
+
+	want := "RESULT     COUNT\nnodes      3\nbinaries   1\nfailed     1\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if messages := result.errorMessages(); len(messages) != 1 || messages[0] != "/site/broken: invalid node" {
+		t.Errorf("unexpected error messages %v", messages)
+	}
+}
@@ -0,0 +1,365 @@
+package export
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const EXPORT_NAMESPACE = "urn:enonic:xp:export:1.0"
+const NODE_XML_FILE = "node.xml"
+const MANUAL_CHILD_ORDER_FILE = "manualChildOrder.txt"
+const SYSTEM_DIR = "_"
+const BINARY_DIR = "bin"
+
+// keys of typed values in node definitions, e.g. {"@type": "dateTime", "@value": "2024-01-01T00:00:00Z"}
+const TYPE_KEY = "@type"
+const VALUE_KEY = "@value"
+
+const (
+	TYPE_STRING           = "string"
+	TYPE_BOOLEAN          = "boolean"
+	TYPE_LONG             = "long"
+	TYPE_DOUBLE           = "double"
+	TYPE_PROPERTY_SET     = "property-set"
+	TYPE_BINARY_REFERENCE = "binaryReference"
+	TYPE_DATE_TIME        = "dateTime"
+)
+
+var propertyTypes = []string{
+	TYPE_STRING, TYPE_BOOLEAN, TYPE_LONG, TYPE_DOUBLE, TYPE_PROPERTY_SET, TYPE_BINARY_REFERENCE, TYPE_DATE_TIME,
+	"xml", "localDate", "localDateTime", "localTime", "geoPoint", "reference", "link",
+}
+
+// NodeDefinition is the readable form of a node.xml kept in node.json or node.yaml files.
+// Data maps property names to a value or a list of values, strings, booleans, numbers and objects
+// are strings, booleans, longs or doubles and property sets, other types are given as typed values.
+type NodeDefinition struct {
+	Id                 string                 `json:"id,omitempty" yaml:"id,omitempty"`
+	NodeType           string                 `json:"nodeType,omitempty" yaml:"nodeType,omitempty"`
+	ChildOrder         string                 `json:"childOrder,omitempty" yaml:"childOrder,omitempty"`
+	ManualChildOrder   []string               `json:"manualChildOrder,omitempty" yaml:"manualChildOrder,omitempty"`
+	Timestamp          string                 `json:"timestamp,omitempty" yaml:"timestamp,omitempty"`
+	InheritPermissions *bool                  `json:"inheritPermissions,omitempty" yaml:"inheritPermissions,omitempty"`
+	Permissions        []PermissionDefinition `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+	Data               map[string]interface{} `json:"data,omitempty" yaml:"data,omitempty"`
+	IndexConfigs       string                 `json:"indexConfigs,omitempty" yaml:"indexConfigs,omitempty"`
+}
+
+type PermissionDefinition struct {
+	Principal string   `json:"principal" yaml:"principal"`
+	Allow     []string `json:"allow,omitempty" yaml:"allow,omitempty"`
+	Deny      []string `json:"deny,omitempty" yaml:"deny,omitempty"`
+}
+
+type xmlNode struct {
+	XMLName            xml.Name        `xml:"node"`
+	Namespace          string          `xml:"xmlns,attr,omitempty"`
+	Id                 string          `xml:"id,omitempty"`
+	ChildOrder         string          `xml:"childOrder,omitempty"`
+	NodeType           string          `xml:"nodeType,omitempty"`
+	Timestamp          string          `xml:"timestamp,omitempty"`
+	InheritPermissions *bool           `xml:"inheritPermissions,omitempty"`
+	Permissions        *xmlPermissions `xml:"permissions"`
+	Data               xmlPropertySet  `xml:"data"`
+	IndexConfigs       *xmlRaw         `xml:"indexConfigs"`
+}
+
+type xmlPermissions struct {
+	Principals []xmlPrincipal `xml:"principal"`
+}
+
+type xmlPrincipal struct {
+	Key   string    `xml:"key,attr"`
+	Allow xmlValues `xml:"allow"`
+	Deny  xmlValues `xml:"deny"`
+}
+
+type xmlValues struct {
+	Values []string `xml:"value"`
+}
+
+type xmlPropertySet struct {
+	Properties []xmlProperty `xml:",any"`
+}
+
+// xmlProperty is a value of node data, the element name is its type
+type xmlProperty struct {
+	XMLName    xml.Name
+	Name       string        `xml:"name,attr"`
+	IsNull     bool          `xml:"isNull,attr,omitempty"`
+	Value      string        `xml:",chardata"`
+	Properties []xmlProperty `xml:",any"`
+}
+
+type xmlRaw struct {
+	Inner string `xml:",innerxml"`
+}
+
+func (p xmlProperty) kind() string {
+	return p.XMLName.Local
+}
+
+func parseNodeXml(data []byte) (*NodeDefinition, error) {
+	var node xmlNode
+	if err := xml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+
+	def := &NodeDefinition{
+		Id:                 node.Id,
+		NodeType:           node.NodeType,
+		ChildOrder:         node.ChildOrder,
+		Timestamp:          node.Timestamp,
+		InheritPermissions: node.InheritPermissions,
+		Data:               propertiesToData(node.Data.Properties),
+	}
+	if node.Permissions != nil {
+		for _, principal := range node.Permissions.Principals {
+			def.Permissions = append(def.Permissions, PermissionDefinition{
+				Principal: principal.Key,
+				Allow:     principal.Allow.Values,
+				Deny:      principal.Deny.Values,
+			})
+		}
+	}
+	if node.IndexConfigs != nil {
+		def.IndexConfigs = node.IndexConfigs.Inner
+	}
+	return def, nil
+}
+
+func (def *NodeDefinition) toNodeXml() ([]byte, error) {
+	properties, err := dataToProperties(def.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	node := xmlNode{
+		Namespace:          EXPORT_NAMESPACE,
+		Id:                 def.Id,
+		ChildOrder:         def.ChildOrder,
+		NodeType:           def.NodeType,
+		Timestamp:          def.Timestamp,
+		InheritPermissions: def.InheritPermissions,
+		Data:               xmlPropertySet{Properties: properties},
+	}
+	if len(def.Permissions) > 0 {
+		node.Permissions = &xmlPermissions{}
+		for _, permission := range def.Permissions {
+			if permission.Principal == "" {
+				return nil, errors.New("permission without principal")
+			}
+			node.Permissions.Principals = append(node.Permissions.Principals, xmlPrincipal{
+				Key:   permission.Principal,
+				Allow: xmlValues{Values: permission.Allow},
+				Deny:  xmlValues{Values: permission.Deny},
+			})
+		}
+	}
+	if def.IndexConfigs != "" {
+		node.IndexConfigs = &xmlRaw{Inner: def.IndexConfigs}
+	}
+
+	data, err := xml.MarshalIndent(node, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// binaryReferences lists the binaryReference values of the node data
+func (def *NodeDefinition) binaryReferences() ([]string, error) {
+	properties, err := dataToProperties(def.Data)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	var collect func(properties []xmlProperty)
+	collect = func(properties []xmlProperty) {
+		for _, p := range properties {
+			switch p.kind() {
+			case TYPE_BINARY_REFERENCE:
+				if !p.IsNull {
+					refs = append(refs, p.Value)
+				}
+			case TYPE_PROPERTY_SET:
+				collect(p.Properties)
+			}
+		}
+	}
+	collect(properties)
+	return refs, nil
+}
+
+// dataToProperties converts the data of a node definition to node.xml properties, sorted by name
+func dataToProperties(data map[string]interface{}) ([]xmlProperty, error) {
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	properties := make([]xmlProperty, 0)
+	for _, name := range names {
+		values, isList := data[name].([]interface{})
+		if !isList {
+			values = []interface{}{data[name]}
+		}
+		for _, value := range values {
+			property, err := valueToProperty(name, value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "property '%s'", name)
+			}
+			properties = append(properties, property)
+		}
+	}
+	return properties, nil
+}
+
+func valueToProperty(name string, value interface{}) (xmlProperty, error) {
+	property := xmlProperty{Name: name}
+	kind := TYPE_STRING
+
+	switch v := value.(type) {
+	case nil:
+		property.IsNull = true
+	case string:
+		property.Value = v
+	case bool:
+		kind = TYPE_BOOLEAN
+		property.Value = strconv.FormatBool(v)
+	case int:
+		kind = TYPE_LONG
+		property.Value = strconv.Itoa(v)
+	case int64:
+		kind = TYPE_LONG
+		property.Value = strconv.FormatInt(v, 10)
+	case uint64:
+		kind = TYPE_LONG
+		property.Value = strconv.FormatUint(v, 10)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			kind = TYPE_LONG
+			property.Value = strconv.FormatInt(int64(v), 10)
+		} else {
+			kind = TYPE_DOUBLE
+			property.Value = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			kind = TYPE_LONG
+		} else {
+			kind = TYPE_DOUBLE
+		}
+		property.Value = v.String()
+	case time.Time:
+		kind = TYPE_DATE_TIME
+		property.Value = v.UTC().Format(time.RFC3339Nano)
+	case map[string]interface{}:
+		if typed, isTyped := v[TYPE_KEY]; isTyped {
+			return typedValueToProperty(name, typed, v)
+		}
+		kind = TYPE_PROPERTY_SET
+		children, err := dataToProperties(v)
+		if err != nil {
+			return property, err
+		}
+		property.Properties = children
+	default:
+		return property, errors.Errorf("unsupported value %v", value)
+	}
+
+	property.XMLName = xml.Name{Local: kind}
+	return property, nil
+}
+
+func typedValueToProperty(name string, typed interface{}, value map[string]interface{}) (xmlProperty, error) {
+	kind, _ := typed.(string)
+	if !isPropertyType(kind) {
+		return xmlProperty{}, errors.Errorf("unknown type '%v', expected one of %v", typed, propertyTypes)
+	}
+
+	property := xmlProperty{XMLName: xml.Name{Local: kind}, Name: name}
+	raw := value[VALUE_KEY]
+	switch {
+	case raw == nil:
+		property.IsNull = true
+	case kind == TYPE_PROPERTY_SET:
+		set, isSet := raw.(map[string]interface{})
+		if !isSet {
+			return property, errors.New("value of a property-set must be an object")
+		}
+		children, err := dataToProperties(set)
+		if err != nil {
+			return property, err
+		}
+		property.Properties = children
+	default:
+		if converted, err := valueToProperty(name, raw); err == nil && converted.kind() != TYPE_PROPERTY_SET {
+			property.Value = converted.Value
+		} else {
+			return property, errors.Errorf("value of a %s must be a scalar", kind)
+		}
+	}
+	return property, nil
+}
+
+func isPropertyType(kind string) bool {
+	for _, known := range propertyTypes {
+		if known == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// propertiesToData converts node.xml properties to the data of a node definition,
+// values are written as plain JSON values when reading them back gives the same type
+func propertiesToData(properties []xmlProperty) map[string]interface{} {
+	data := make(map[string]interface{})
+	for _, property := range properties {
+		value := propertyToValue(property)
+		if existing, found := data[property.Name]; found {
+			if list, isList := existing.([]interface{}); isList {
+				data[property.Name] = append(list, value)
+			} else {
+				data[property.Name] = []interface{}{existing, value}
+			}
+		} else {
+			data[property.Name] = value
+		}
+	}
+	return data
+}
+
+func propertyToValue(property xmlProperty) interface{} {
+	kind := property.kind()
+	if property.IsNull {
+		if kind == TYPE_STRING {
+			return nil
+		}
+		return map[string]interface{}{TYPE_KEY: kind, VALUE_KEY: nil}
+	}
+
+	switch kind {
+	case TYPE_STRING:
+		return property.Value
+	case TYPE_BOOLEAN:
+		if value, err := strconv.ParseBool(property.Value); err == nil {
+			return value
+		}
+	case TYPE_LONG:
+		if value, err := strconv.ParseInt(property.Value, 10, 64); err == nil && math.Abs(float64(value)) < 1<<53 {
+			return value
+		}
+	case TYPE_PROPERTY_SET:
+		return propertiesToData(property.Properties)
+	}
+	return map[string]interface{}{TYPE_KEY: kind, VALUE_KEY: property.Value}
+}
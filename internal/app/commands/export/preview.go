@@ -0,0 +1,296 @@
+package export
+
+import (
+	"bytes"
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/commands/sandbox"
+	"cli-enonic/internal/app/commands/storage"
+	"cli-enonic/internal/app/util"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+// XSLT 1.0 processor of libxslt, XP applies the transformations with the XSLT 1.0 processor of the JDK
+const XSLT_PROCESSOR = "xsltproc"
+const DEFAULT_PREVIEW_LIMIT = 10
+
+// previewImport applies the xsl of the import flags to the node.xml files of the export locally and prints
+// the difference it makes to each node, the export has to be given with --upload or be in the --sandbox
+func previewImport(c *cli.Context, out io.Writer) {
+	if c.String("xsl-source") == "" {
+		fmt.Fprintln(os.Stderr, "Preview shows the changes of an XSL transformation, --xsl-source is required")
+		os.Exit(1)
+	}
+	sandboxName := sandbox.EnsureSandboxFlag(c)
+	// checked before the export is fetched, so that nothing is left behind when they are missing
+	xslPath := localXslPath(c.String("xsl-source"), sandboxName)
+	processor, err := exec.LookPath(XSLT_PROCESSOR)
+	util.Fatal(err, fmt.Sprintf("Preview needs %s (libxslt) on the PATH:", XSLT_PROCESSOR))
+
+	exportDir, cleanup := localExportDir(c, sandboxName)
+	transform := func(nodeFile string) ([]byte, error) {
+		return runXslt(processor, xslPath, xslParams, nodeFile)
+	}
+	stats, err := previewNodes(out, exportDir, transform, c.Int("limit"))
+	// the export is removed before checking the result, util.Fatal exits without running deferred calls
+	cleanup()
+	util.Fatal(err, "Could not preview import:")
+
+	fmt.Fprintf(os.Stderr, "Checked %d nodes, %d changed by the transformation\n", stats.Checked, stats.Changed)
+	if stats.Stopped {
+		fmt.Fprintf(os.Stderr, "Stopped after %d changed nodes, use --limit 0 to check all of them\n", stats.Changed)
+	}
+}
+
+// localExportDir returns the folder of the export to preview and a function removing it if it is temporary
+func localExportDir(c *cli.Context, sandboxName string) (string, func()) {
+	upload := c.String("upload")
+	if upload == "" {
+		if sandboxName == "" {
+			fmt.Fprintln(os.Stderr, "Preview runs locally, give the export with --upload or the sandbox it is in with --sandbox")
+			os.Exit(1)
+		}
+		dir := filepath.Join(getSandboxExportDir(sandboxName), c.String("t"))
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "Export '%s' can not be found in sandbox \"%s\"\n", c.String("t"), sandboxName)
+			os.Exit(1)
+		}
+		return dir, func() {}
+	}
+
+	localFile, removeLocalFile := storage.FetchFile(c, upload)
+	info, err := os.Stat(localFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Export '%s' can not be found\n", upload)
+		os.Exit(1)
+	}
+	if info.IsDir() {
+		return localFile, removeLocalFile
+	}
+
+	tmpDir, err := os.MkdirTemp("", "enonic-export-")
+	util.Fatal(err, "Could not create temporary folder:")
+	cleanup := func() {
+		os.RemoveAll(tmpDir)
+		removeLocalFile()
+	}
+	if err = common.ExtractArchive(localFile, tmpDir); err != nil {
+		cleanup()
+		util.Fatal(err, "Could not read export archive:")
+	}
+	return tmpDir, cleanup
+}
+
+// localXslPath finds the xsl file, which is relative to the export folder of XP, in the sandbox or in the current folder
+func localXslPath(xslSource, sandboxName string) string {
+	candidates := []string{xslSource}
+	if sandboxName != "" {
+		candidates = append([]string{filepath.Join(getSandboxExportDir(sandboxName), xslSource)}, candidates...)
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	fmt.Fprintf(os.Stderr, "XSL file '%s' can not be found\n", xslSource)
+	os.Exit(1)
+	return ""
+}
+
+func runXslt(processor, xslPath string, params map[string]string, nodeFile string) ([]byte, error) {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := make([]string, 0)
+	for _, name := range names {
+		args = append(args, "--stringparam", name, params[name])
+	}
+	args = append(args, xslPath, nodeFile)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(processor, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// previewNodes prints the diff of every node the transformation changes, stopping after limit changed nodes if not 0
+func previewNodes(out io.Writer, exportDir string, transform func(nodeFile string) ([]byte, error), limit int) (*PreviewStats, error) {
+	nodeFiles, err := findNodeXmlFiles(exportDir)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &PreviewStats{}
+	for _, nodeFile := range nodeFiles {
+		if limit > 0 && stats.Changed >= limit {
+			stats.Stopped = true
+			break
+		}
+		nodePath := nodePathOf(exportDir, nodeFile)
+
+		original, err := os.ReadFile(nodeFile)
+		if err != nil {
+			return nil, err
+		}
+		transformed, err := transform(nodeFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "transformation of node '%s' failed", nodePath)
+		}
+		before, err := normalizeXml(original)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid node.xml of node '%s'", nodePath)
+		}
+		after, err := normalizeXml(transformed)
+		if err != nil {
+			return nil, errors.Wrapf(err, "transformation of node '%s' is not valid XML", nodePath)
+		}
+
+		stats.Checked++
+		if diff := util.UnifiedDiff(nodePath, nodePath+" (transformed)", before, after); diff != "" {
+			stats.Changed++
+			fmt.Fprintln(out, diff)
+		}
+	}
+	return stats, nil
+}
+
+// findNodeXmlFiles lists the node.xml files of the export sorted by node path
+func findNodeXmlFiles(exportDir string) ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.Walk(exportDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && info.Name() == NODE_XML_FILE && filepath.Base(filepath.Dir(path)) == SYSTEM_DIR {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool {
+		return nodePathOf(exportDir, files[i]) < nodePathOf(exportDir, files[j])
+	})
+	return files, err
+}
+
+// nodePathOf returns the path of the node in the export, like /content/site
+func nodePathOf(exportDir, nodeFile string) string {
+	rel, err := filepath.Rel(exportDir, filepath.Dir(filepath.Dir(nodeFile)))
+	if err != nil || rel == "." {
+		return "/"
+	}
+	return "/" + filepath.ToSlash(rel)
+}
+
+// normalizeXml prints the document with one element per line and sorted attributes,
+// so that the way the XSL processor formats its output does not show in the diff
+func normalizeXml(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	root := &xmlElement{}
+	stack := []*xmlElement{root}
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := &xmlElement{name: qualifiedName(t.Name), attrs: t.Attr}
+			parent.children = append(parent.children, element)
+			stack = append(stack, element)
+		case xml.EndElement:
+			if len(stack) == 1 {
+				return "", errors.Errorf("unexpected end element %s", qualifiedName(t.Name))
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			parent.text += string(t)
+		}
+	}
+	if len(stack) != 1 {
+		return "", errors.New("unexpected end of document")
+	}
+
+	var out strings.Builder
+	for _, element := range root.children {
+		element.write(&out, 0)
+	}
+	return out.String(), nil
+}
+
+type xmlElement struct {
+	name     string
+	attrs    []xml.Attr
+	text     string
+	children []*xmlElement
+}
+
+func (e *xmlElement) write(out *strings.Builder, depth int) {
+	indent := strings.Repeat("  ", depth)
+	out.WriteString(indent + "<" + e.name)
+
+	attrs := make([]string, len(e.attrs))
+	for i, attr := range e.attrs {
+		var value bytes.Buffer
+		xml.EscapeText(&value, []byte(attr.Value))
+		attrs[i] = fmt.Sprintf(` %s="%s"`, qualifiedName(attr.Name), value.String())
+	}
+	sort.Strings(attrs)
+	out.WriteString(strings.Join(attrs, ""))
+
+	var text bytes.Buffer
+	if len(e.children) == 0 {
+		xml.EscapeText(&text, []byte(e.text))
+	} else {
+		// text around child elements is only indentation in node.xml
+		xml.EscapeText(&text, []byte(strings.TrimSpace(e.text)))
+	}
+
+	switch {
+	case len(e.children) == 0 && text.Len() == 0:
+		out.WriteString("/>\n")
+	case len(e.children) == 0:
+		out.WriteString(">" + text.String() + "</" + e.name + ">\n")
+	default:
+		out.WriteString(">\n")
+		if text.Len() > 0 {
+			out.WriteString(indent + "  " + text.String() + "\n")
+		}
+		for _, child := range e.children {
+			child.write(out, depth+1)
+		}
+		out.WriteString(indent + "</" + e.name + ">\n")
+	}
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+type PreviewStats struct {
+	Checked int
+	Changed int
+	Stopped bool
+}
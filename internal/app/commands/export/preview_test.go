@@ -0,0 +1,79 @@
+package export
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeXmlIgnoresFormatting(t *testing.T) {
+	compact := `<?xml version="1.0"?><node xmlns="urn:enonic:xp:export:1.0"><data><string name="a" isNull="true"/><string name="b">x</string></data></node>`
+	indented := `<?xml version="1.0" encoding="UTF-8"?>
+<node xmlns="urn:enonic:xp:export:1.0">
+  <!-- comment -->
+  <data>
+    <string isNull="true" name="a"></string>
+    <string name="b">x</string>
+  </data>
+</node>
+`
+	a, err := normalizeXml([]byte(compact))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := normalizeXml([]byte(indented))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a != b {
+		t.Errorf("normalized documents differ:\n%s\n%s", a, b)
+	}
+	if !strings.Contains(a, `    <string isNull="true" name="a"/>`) {
+		t.Errorf("unexpected normalized document:\n%s", a)
+	}
+
+	if _, err = normalizeXml([]byte("<node><data></node>")); err == nil {
+		t.Error("expected an error for invalid XML")
+	}
+}
+
+func TestPreviewNodes(t *testing.T) {
+	exportDir := t.TempDir()
+	for _, node := range []string{"", "site", "site/about", "site/contact"} {
+		writeFile(t, filepath.Join(exportDir, filepath.FromSlash(node), SYSTEM_DIR, NODE_XML_FILE),
+			`<node><data><string name="type">com.example.old:page</string></data></node>`)
+	}
+	// the root node is not changed by the transformation
+	transform := func(nodeFile string) ([]byte, error) {
+		data, _ := os.ReadFile(nodeFile)
+		if nodePathOf(exportDir, nodeFile) == "/" {
+			return data, nil
+		}
+		return []byte(strings.ReplaceAll(string(data), "com.example.old", "com.example.new")), nil
+	}
+
+	var out bytes.Buffer
+	stats, err := previewNodes(&out, exportDir, transform, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Checked != 4 || stats.Changed != 3 || stats.Stopped {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	for _, expected := range []string{"--- /site\n+++ /site (transformed)\n", "-    <string name=\"type\">com.example.old:page</string>\n+    <string name=\"type\">com.example.new:page</string>\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("output misses %q:\n%s", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	stats, err = previewNodes(&out, exportDir, transform, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Changed != 2 || !stats.Stopped || strings.Contains(out.String(), "/site/contact") {
+		t.Errorf("expected preview to stop after 2 changed nodes, got %+v:\n%s", stats, out.String())
+	}
+}
@@ -0,0 +1,153 @@
+package export
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/commands/sandbox"
+	"cli-enonic/internal/app/commands/storage"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const DOWNLOAD_URL = "repo/export/download"
+const UPLOAD_URL = "repo/export/upload"
+const UPLOAD_COMPLETE_URL = "repo/export/upload/complete"
+
+func getSandboxExportDir(sandboxName string) string {
+	return filepath.Join(sandbox.GetSandboxHomePath(sandboxName), "data", "export")
+}
+
+// ensureDownloadLocation resolves where the export is saved to, asking before overwriting an existing file
+func ensureDownloadLocation(c *cli.Context, name string, force bool) string {
+	output := c.String("download")
+	defaultName := name + ".zip"
+	if storage.IsStorageUrl(output) {
+		return storage.EnsureLocation(c, output, defaultName, force)
+	}
+	if info, err := os.Stat(output); err == nil && info.IsDir() {
+		output = filepath.Join(output, defaultName)
+	}
+	if _, err := os.Stat(output); err == nil && !force && !util.PromptBool(fmt.Sprintf("File '%s' already exists. Overwrite", output), false) {
+		os.Exit(1)
+	}
+	return output
+}
+
+// saveExport writes the named export as a zip archive to the location, copying it from the sandbox home if set.
+// The export made at the time is saved, the file staged by a previous run holds an older export of the same name.
+func saveExport(c *cli.Context, name, location, sandboxName string, made time.Time) {
+	source := fmt.Sprintf("export \"%s\" made %s", name, made.Format(time.RFC3339))
+	storage.SaveFile(c, location, source, func(output string) {
+		if sandboxName != "" {
+			util.Fatal(archiveExportDir(filepath.Join(getSandboxExportDir(sandboxName), name), output), "Could not copy export:")
+			return
+		}
+
+		checksum, err := common.DownloadArchive(c, DOWNLOAD_URL+"?name="+url.QueryEscape(name), output, "Downloading export")
+		util.Fatal(err, "Could not download export:")
+		if err = common.VerifyChecksum(output, checksum); err != nil {
+			os.Remove(output)
+			util.Fatal(err, "Downloaded export is corrupted:")
+		}
+	})
+}
+
+func archiveExportDir(dir, target string) error {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return errors.Errorf("export folder '%s' can not be found", dir)
+	}
+	file, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err = common.ArchiveDir(dir, file); err != nil {
+		file.Close()
+		os.Remove(target)
+		return err
+	}
+	return nil
+}
+
+// exportNameOf returns the export name an archive or a folder is uploaded as by default
+func exportNameOf(source string) string {
+	return strings.TrimSuffix(filepath.Base(strings.TrimRight(source, "/"+string(os.PathSeparator))), ".zip")
+}
+
+// uploadExport sends the archive or folder to the remote, or copies it into the sandbox home if set
+func uploadExport(c *cli.Context, name, source, sandboxName string, force bool) {
+	localFile, removeLocalFile := storage.FetchFile(c, source)
+	defer removeLocalFile()
+
+	info, err := os.Stat(localFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Export '%s' can not be found\n", source)
+		os.Exit(1)
+	}
+
+	if sandboxName != "" {
+		target := filepath.Join(getSandboxExportDir(sandboxName), name)
+		if _, err = os.Stat(target); err == nil {
+			if !force && !util.PromptBool(fmt.Sprintf("Export '%s' already exists in sandbox \"%s\". Overwrite", name, sandboxName), false) {
+				os.Exit(1)
+			}
+			util.Fatal(os.RemoveAll(target), "Could not remove existing export:")
+		}
+		if info.IsDir() {
+			err = copyExportDir(localFile, target)
+		} else {
+			err = common.ExtractArchive(localFile, target)
+		}
+		if err != nil {
+			os.RemoveAll(target)
+			util.Fatal(err, "Could not copy export:")
+		}
+		fmt.Fprintf(os.Stderr, "Copied export \"%s\" to sandbox \"%s\"\n", name, sandboxName)
+		return
+	}
+
+	archive := localFile
+	if info.IsDir() {
+		// a unique temp file, a predictable name in the shared temp folder could be taken by another user
+		temp, err := os.CreateTemp("", name+"-*.zip")
+		util.Fatal(err, "Could not archive export:")
+		temp.Close()
+		archive = temp.Name()
+		if err = archiveExportDir(localFile, archive); err != nil {
+			os.Remove(archive)
+			util.Fatal(err, "Could not archive export:")
+		}
+		defer os.Remove(archive)
+	}
+
+	result, err := common.UploadArchive(c, UPLOAD_URL, UPLOAD_COMPLETE_URL, name, archive, "Uploading export")
+	if err != nil {
+		// the deferred removal does not run on fatal errors, a staged download is kept to retry with
+		if archive != localFile {
+			os.Remove(archive)
+		}
+		util.Fatal(err, "Could not upload export:")
+	}
+	fmt.Fprintf(os.Stderr, "Uploaded export \"%s\", checksum verified\n", result.Name)
+}
+
+func copyExportDir(source, target string) error {
+	return filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		return common.CopyFile(path, filepath.Join(target, rel))
+	})
+}
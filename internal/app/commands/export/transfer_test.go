@@ -0,0 +1,102 @@
+package export
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"crypto/sha256"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+func TestExportNameOf(t *testing.T) {
+	cases := map[string]string{
+		"myexport.zip":             "myexport",
+		"/tmp/exports/myexport":    "myexport",
+		"/tmp/exports/myexport/":   "myexport",
+		"s3://bucket/myexport.zip": "myexport",
+	}
+	for source, want := range cases {
+		if got := exportNameOf(source); got != want {
+			t.Errorf("exportNameOf(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestEnsureDownloadLocationInFolder(t *testing.T) {
+	dir := t.TempDir()
+	set := flag.NewFlagSet("test", 0)
+	set.String("download", dir, "")
+	c := cli.NewContext(nil, set, nil)
+
+	if got := ensureDownloadLocation(c, "myexport", true); got != filepath.Join(dir, "myexport.zip") {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestCopyExportDirRoundTrip(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "myexport")
+	os.MkdirAll(filepath.Join(source, "content", "_", "node.xml"), 0755)
+	os.WriteFile(filepath.Join(source, "content", "_", "node.xml", "node.xml"), []byte("<node/>"), 0644)
+	os.WriteFile(filepath.Join(source, "export.properties"), []byte("version=7"), 0644)
+
+	archive := filepath.Join(t.TempDir(), "myexport.zip")
+	if err := archiveExportDir(source, archive); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	extracted := filepath.Join(t.TempDir(), "extracted")
+	if err := common.ExtractArchive(archive, extracted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	copied := filepath.Join(t.TempDir(), "copied")
+	if err := copyExportDir(source, copied); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, dir := range []string{extracted, copied} {
+		if content, _ := os.ReadFile(filepath.Join(dir, "content", "_", "node.xml", "node.xml")); string(content) != "<node/>" {
+			t.Errorf("unexpected node.xml content in %s: %q", dir, content)
+		}
+	}
+	if err := archiveExportDir(filepath.Join(t.TempDir(), "missing"), archive); err == nil {
+		t.Error("expected an error for a missing export folder")
+	}
+}
+
+func TestSaveExportDownloads(t *testing.T) {
+	content := []byte("export archive")
+	sum := sha256.Sum256(content)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+DOWNLOAD_URL || r.URL.Query().Get("name") != "myexport" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set(common.DIGEST_HEADER, common.DIGEST_SHA256+base64.StdEncoding.EncodeToString(sum[:]))
+		w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	home := t.TempDir()
+	os.MkdirAll(filepath.Join(home, ".enonic"), 0755)
+	t.Setenv("ENONIC_CLI_HOME_PATH", home)
+	t.Setenv("ENONIC_CLI_REMOTE_URL", server.URL)
+	t.Setenv("ENONIC_CLI_REMOTE_USER", "su")
+	t.Setenv("ENONIC_CLI_REMOTE_PASS", "password")
+
+	target := filepath.Join(t.TempDir(), "myexport.zip")
+	c := cli.NewContext(nil, flag.NewFlagSet("test", 0), nil)
+	saveExport(c, "myexport", target, "", time.Now())
+
+	if saved, _ := os.ReadFile(target); string(saved) != string(content) {
+		t.Errorf("unexpected saved content: %q", saved)
+	}
+}
@@ -0,0 +1,198 @@
+package export
+
+import (
+	"bytes"
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v3"
+)
+
+const FORMAT_JSON = "json"
+const FORMAT_YAML = "yaml"
+
+var Unpack = cli.Command{
+	Name:      "unpack",
+	Usage:     "Convert an export to a folder of JSON or YAML node definitions and their attachments.",
+	ArgsUsage: "<export folder|file.zip>",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "Folder to write the node definitions to, defaults to <export name>-nodes",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "Format of the node definitions: json or yaml",
+			Value: FORMAT_JSON,
+		},
+		common.FORCE_FLAG,
+	},
+	Action: func(c *cli.Context) error {
+
+		source := c.Args().First()
+		if _, err := os.Stat(source); source == "" || err != nil {
+			fmt.Fprintf(os.Stderr, "Export '%s' can not be found\n", source)
+			os.Exit(1)
+		}
+		format := strings.ToLower(c.String("format"))
+		if format != FORMAT_JSON && format != FORMAT_YAML {
+			fmt.Fprintf(os.Stderr, "Unknown format '%s', use json or yaml\n", c.String("format"))
+			os.Exit(1)
+		}
+		output := c.String("output")
+		if output == "" {
+			output = exportNameOf(source) + "-nodes"
+		}
+		common.EnsureOverwrite(c, output)
+
+		stats, err := unpackExportFrom(source, output, format)
+		util.Fatal(err, "Could not unpack export:")
+
+		fmt.Fprintf(os.Stderr, "Unpacked %d nodes and %d binaries to '%s'\n", stats.Nodes, stats.Binaries, output)
+		return nil
+	},
+}
+
+// unpackExportFrom unpacks an export folder or zip archive
+func unpackExportFrom(source, output, format string) (*ExportStats, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats *ExportStats
+	err = replaceOutput(output, true, isNodesDir, func(tmp string) error {
+		var err error
+		if info.IsDir() {
+			stats, err = unpackExport(source, tmp, format)
+			return err
+		}
+
+		tmpDir, err := os.MkdirTemp("", "enonic-export-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+
+		if err = common.ExtractArchive(source, tmpDir); err != nil {
+			return err
+		}
+		stats, err = unpackExport(tmpDir, tmp, format)
+		return err
+	})
+	return stats, err
+}
+
+func unpackExport(source, target, format string) (*ExportStats, error) {
+	stats := &ExportStats{}
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return nil, err
+	}
+	return stats, unpackNode(source, target, format, stats)
+}
+
+func unpackNode(source, target, format string, stats *ExportStats) error {
+	systemDir := filepath.Join(source, SYSTEM_DIR)
+	if _, err := os.Stat(filepath.Join(systemDir, NODE_XML_FILE)); err == nil {
+		if err = readNode(systemDir, target, format, stats); err != nil {
+			return err
+		}
+	}
+
+	entries, err := os.ReadDir(source)
+	if err != nil {
+		return err
+	}
+	children := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != SYSTEM_DIR {
+			children = append(children, entry.Name())
+		}
+	}
+	sort.Strings(children)
+
+	for _, child := range children {
+		childTarget := filepath.Join(target, child)
+		if _, err = os.Stat(childTarget); err == nil {
+			return errors.Errorf("attachment and child node have the same name '%s'", childTarget)
+		}
+		if err = os.MkdirAll(childTarget, 0755); err != nil {
+			return err
+		}
+		if err = unpackNode(filepath.Join(source, child), childTarget, format, stats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readNode(systemDir, target, format string, stats *ExportStats) error {
+	nodeXmlPath := filepath.Join(systemDir, NODE_XML_FILE)
+	nodeXml, err := os.ReadFile(nodeXmlPath)
+	if err != nil {
+		return err
+	}
+	def, err := parseNodeXml(nodeXml)
+	if err != nil {
+		return errors.Wrapf(err, "invalid node '%s'", nodeXmlPath)
+	}
+	if order, err := os.ReadFile(filepath.Join(systemDir, MANUAL_CHILD_ORDER_FILE)); err == nil {
+		for _, name := range strings.Split(string(order), "\n") {
+			if name = strings.TrimSpace(name); name != "" {
+				def.ManualChildOrder = append(def.ManualChildOrder, name)
+			}
+		}
+	}
+
+	data, err := encodeNodeDefinition(def, format)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(target, "node."+format), data, 0644); err != nil {
+		return err
+	}
+	stats.Nodes++
+
+	binaries, err := os.ReadDir(filepath.Join(systemDir, BINARY_DIR))
+	if err != nil {
+		return nil
+	}
+	for _, binary := range binaries {
+		if binary.IsDir() {
+			continue
+		}
+		if containsName(definitionFiles, binary.Name()) {
+			return errors.Errorf("binary '%s' of '%s' has the name of a node definition", binary.Name(), nodeXmlPath)
+		}
+		if err = common.CopyFile(filepath.Join(systemDir, BINARY_DIR, binary.Name()), filepath.Join(target, binary.Name())); err != nil {
+			return err
+		}
+		stats.Binaries++
+	}
+	return nil
+}
+
+func encodeNodeDefinition(def *NodeDefinition, format string) ([]byte, error) {
+	if format == FORMAT_YAML {
+		buf := new(bytes.Buffer)
+		encoder := yaml.NewEncoder(buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(def); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), encoder.Close()
+	}
+	data, err := json.MarshalIndent(def, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
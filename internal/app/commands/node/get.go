@@ -0,0 +1,39 @@
+package node
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli"
+)
+
+var Get = cli.Command{
+	Name:      "get",
+	Usage:     "Show a node by path or id.",
+	ArgsUsage: "<repo-name>:<branch-name>:<node-path|node-id>",
+	Flags:     append([]cli.Flag{JSON_FLAG, common.FORCE_FLAG}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		repoPath := ensureRepoPathArg(c, true)
+
+		req := createNodeRequest(c, "node/get", map[string]interface{}{
+			"repository": repoPath.Repo,
+			"branch":     repoPath.Branch,
+			"key":        repoPath.Node,
+		})
+		res := common.SendRequest(c, req, "Loading node")
+
+		var node Node
+		common.ParseResponse(res, &node)
+
+		if c.Bool("json") {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(node))
+		} else {
+			printNode(os.Stdout, node)
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,44 @@
+package node
+
+import (
+	"cli-enonic/internal/app/commands/common"
+
+	"github.com/urfave/cli"
+)
+
+var Ls = cli.Command{
+	Name:      "ls",
+	Usage:     "List child nodes of a node.",
+	ArgsUsage: "<repo-name>:<branch-name>:<node-path>",
+	Flags: append(append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "sort",
+			Usage: "Order of the children, defaults to the child order of the parent, e.g. '_ts DESC'",
+		},
+		JSON_FLAG,
+		common.FORCE_FLAG,
+	}, PAGING_FLAGS...), common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		repoPath := ensureRepoPathArg(c, true)
+		ensurePagingFlags(c)
+
+		params := map[string]interface{}{
+			"repository": repoPath.Repo,
+			"branch":     repoPath.Branch,
+			"parentKey":  repoPath.Node,
+			"start":      c.Int("start"),
+			"count":      c.Int("count"),
+		}
+		if sort := c.String("sort"); sort != "" {
+			params["childOrder"] = sort
+		}
+		res := common.SendRequest(c, createNodeRequest(c, "node/children", params), "Loading nodes")
+
+		var result NodesResult
+		common.ParseResponse(res, &result)
+		printNodes(c, &result)
+
+		return nil
+	},
+}
@@ -0,0 +1,175 @@
+package node
+
+import (
+	"bytes"
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+)
+
+const DEFAULT_PAGE_SIZE = 20
+
+var PAGING_FLAGS = []cli.Flag{
+	cli.IntFlag{
+		Name:  "start",
+		Usage: "Index of the first node to show",
+	},
+	cli.IntFlag{
+		Name:  "count",
+		Usage: "Number of nodes to show",
+		Value: DEFAULT_PAGE_SIZE,
+	},
+}
+
+var JSON_FLAG = cli.BoolFlag{
+	Name:  "json",
+	Usage: "Print the nodes as JSON",
+}
+
+// system properties of a node, the other top level properties are its data
+var systemProperties = []string{"_id", "_name", "_path", "_nodeType", "_childOrder", "_state", "_versionKey", "_ts", "_manualOrderValue", "_inheritsPermissions"}
+
+func All() []cli.Command {
+	return []cli.Command{
+		Get,
+		Ls,
+		Query,
+	}
+}
+
+// ensureRepoPathArg parses the first argument as <repo-name>:<branch-name>:<node-path> or <repo-name>:<branch-name>
+func ensureRepoPathArg(c *cli.Context, withNode bool) *common.RepoPath {
+	repoPath, err := common.ParseRepoPath(c.Args().First(), withNode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return repoPath
+}
+
+func ensurePagingFlags(c *cli.Context) {
+	if c.Int("start") < 0 || c.Int("count") < 1 {
+		fmt.Fprintln(os.Stderr, "--start can not be negative and --count has to be 1 or more")
+		os.Exit(1)
+	}
+}
+
+func createNodeRequest(c *cli.Context, url string, params map[string]interface{}) *http.Request {
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(params)
+
+	return common.CreateRequest(c, "POST", url, body)
+}
+
+func printNodes(c *cli.Context, result *NodesResult) {
+	if c.Bool("json") {
+		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+		return
+	}
+	printNodeTable(os.Stdout, result.Hits)
+	common.PrintPage(os.Stderr, "nodes", c.Int("start"), len(result.Hits), result.Total)
+}
+
+func printNodeTable(out io.Writer, nodes []Node) {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, "PATH\tID\tTYPE\tMODIFIED")
+	for _, node := range nodes {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", node.Property("_path"), node.Property("_id"), node.Property("_nodeType"), node.Property("_ts"))
+	}
+	writer.Flush()
+}
+
+func printNode(out io.Writer, node Node) {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	for _, name := range systemProperties {
+		if value, ok := node[name]; ok {
+			fmt.Fprintf(writer, "%s\t%s\n", name, formatValue(value))
+		}
+	}
+	writer.Flush()
+
+	data := make(map[string]interface{})
+	for name, value := range node {
+		if !strings.HasPrefix(name, "_") {
+			data[name] = value
+		}
+	}
+	if len(data) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out)
+	writer = tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, "PROPERTY\tVALUE")
+	flat := flattenData("", data)
+	names := make([]string, 0, len(flat))
+	for name := range flat {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(writer, "%s\t%s\n", name, flat[name])
+	}
+	writer.Flush()
+}
+
+// flattenData turns the property sets of the node data into dotted names, like data.title,
+// and lists into indexed names, like tags[0]
+func flattenData(prefix string, value interface{}) map[string]string {
+	flat := make(map[string]string)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, child := range v {
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			for k, s := range flattenData(name, child) {
+				flat[k] = s
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			for k, s := range flattenData(fmt.Sprintf("%s[%d]", prefix, i), child) {
+				flat[k] = s
+			}
+		}
+	default:
+		flat[prefix] = formatValue(v)
+	}
+	return flat
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Node is a node as returned by XP, with system properties starting with _ next to its data
+type Node map[string]interface{}
+
+func (n Node) Property(name string) string {
+	return formatValue(n[name])
+}
+
+type NodesResult struct {
+	Total int64  `json:"total"`
+	Hits  []Node `json:"hits"`
+}
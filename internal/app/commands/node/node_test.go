@@ -0,0 +1,44 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPrintNode(t *testing.T) {
+	var node Node
+	json.Unmarshal([]byte(`{
+		"_id": "1234",
+		"_name": "site",
+		"_path": "/content/site",
+		"_nodeType": "content",
+		"_permissions": [{"principal": "role:system.everyone"}],
+		"displayName": "Site",
+		"data": {"tags": ["a", "b"], "count": 2},
+		"empty": null
+	}`), &node)
+
+	var out bytes.Buffer
+	printNode(&out, node)
+
+	for _, expected := range []string{"_id         1234\n", "_path       /content/site\n", "PROPERTY       VALUE\n", "data.count     2\n", "data.tags[1]   b\n", "displayName    Site\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("output misses %q:\n%s", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "_permissions") {
+		t.Errorf("unexpected permissions in output:\n%s", out.String())
+	}
+}
+
+func TestPrintNodeTable(t *testing.T) {
+	var out bytes.Buffer
+	printNodeTable(&out, []Node{{"_path": "/content", "_id": "1", "_nodeType": "default", "_ts": "2024-01-01T00:00:00Z"}})
+
+	want := "PATH       ID   TYPE      MODIFIED\n/content   1    default   2024-01-01T00:00:00Z\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,52 @@
+package node
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+var Query = cli.Command{
+	Name:      "query",
+	Usage:     "Find nodes in a branch with a NoQL query.",
+	ArgsUsage: "<repo-name>:<branch-name> '<NoQL query>'",
+	Flags: append(append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "sort",
+			Usage: "Order of the nodes, e.g. '_ts DESC'",
+		},
+		JSON_FLAG,
+		common.FORCE_FLAG,
+	}, PAGING_FLAGS...), common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		repoPath := ensureRepoPathArg(c, false)
+		ensurePagingFlags(c)
+		query := strings.TrimSpace(strings.Join(c.Args().Tail(), " "))
+		if query == "" {
+			fmt.Fprintln(os.Stderr, "NoQL query can not be empty, e.g. \"type = 'portal:site'\"")
+			os.Exit(1)
+		}
+
+		params := map[string]interface{}{
+			"repository": repoPath.Repo,
+			"branch":     repoPath.Branch,
+			"query":      query,
+			"start":      c.Int("start"),
+			"count":      c.Int("count"),
+		}
+		if sort := c.String("sort"); sort != "" {
+			params["sort"] = sort
+		}
+		res := common.SendRequest(c, createNodeRequest(c, "node/query", params), "Querying nodes")
+
+		var result NodesResult
+		common.ParseResponse(res, &result)
+		printNodes(c, &result)
+
+		return nil
+	},
+}
@@ -3,6 +3,8 @@ package remote
 import (
 	"bytes"
 	"cli-enonic/internal/app/util"
+	"fmt"
+	"github.com/pkg/errors"
 	"github.com/urfave/cli"
 	"net/url"
 	"os"
@@ -97,6 +99,66 @@ func writeRemotesData(data RemotesData) {
 	util.EncodeTomlFile(file, data)
 }
 
+// ParseRemote reads a remote given on the command line in the [scheme]://[user:password]@[host]:[port] format,
+// credentials are moved out of the url and the proxy is taken from the environment like for the active remote
+func ParseRemote(text string) (*RemoteData, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.New("remote url can not be empty")
+	}
+	if strings.Index(text, "http") != 0 {
+		text = "http://" + text
+	}
+	remoteUrl, err := ParseMarshalledUrl(text)
+	if err != nil {
+		return nil, err
+	}
+	if remoteUrl.Host == "" {
+		return nil, errors.Errorf("remote url '%s' has no host", text)
+	}
+
+	var user, pass string
+	if remoteUrl.User != nil {
+		user = remoteUrl.User.Username()
+		pass, _ = remoteUrl.User.Password()
+		remoteUrl.User = nil
+	}
+	return &RemoteData{remoteUrl, user, pass, parseUrl(os.Getenv(CLI_REMOTE_PROXY), "")}, nil
+}
+
+// UseRemote replaces the env defined remote for all subsequent requests,
+// commands comparing or moving data between instances switch remotes with it
+func UseRemote(rm *RemoteData) {
+	usedRemote = rm
+}
+
+// EnsureRemoteFlag parses the remote given in the flag, prompting for it while it is missing or not valid
+func EnsureRemoteFlag(c *cli.Context, name string, force bool) *RemoteData {
+	var parsed *RemoteData
+	remoteValidator := func(val interface{}) error {
+		str := val.(string)
+		var err error
+		if parsed, err = ParseRemote(str); err != nil {
+			if force {
+				fmt.Fprintf(os.Stderr, "Remote '%s' is not valid: %s\n", str, err.Error())
+				os.Exit(1)
+			}
+			return errors.Errorf("Remote '%s' is not valid. Format: [scheme]://[user:password]@[host]:[port]: ", str)
+		}
+		return nil
+	}
+
+	util.PromptString(fmt.Sprintf("Enter '%s' remote url", name), c.String(name), "", remoteValidator)
+
+	return parsed
+}
+
+// IsRemoteOverridden tells if requests go to a remote set with UseRemote,
+// the session stored in runtime data belongs to the env defined remote only
+func IsRemoteOverridden() bool {
+	return usedRemote != nil
+}
+
 func getRemoteByName(name string, remotes map[string]RemoteData) (*RemoteData, bool) {
 	if remotes == nil {
 		return nil, false
@@ -105,10 +167,15 @@ func getRemoteByName(name string, remotes map[string]RemoteData) (*RemoteData, b
 	return &rm, prs
 }
 
+var usedRemote *RemoteData
+
 /*
 Env vars remote implementation
 */
 func GetActiveRemote() *RemoteData {
+	if usedRemote != nil {
+		return usedRemote
+	}
 	remoteUrl := parseUrl(os.Getenv(CLI_REMOTE_URL), DEFAULT_REMOTE_URL)
 	user := os.Getenv(CLI_REMOTE_USER)
 	pass := os.Getenv(CLI_REMOTE_PASS)
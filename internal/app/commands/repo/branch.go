@@ -0,0 +1,143 @@
+package repo
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+var Branch = cli.Command{
+	Name:  "branch",
+	Usage: "Create, delete and list branches of a repository.",
+	Subcommands: []cli.Command{
+		BranchCreate,
+		BranchDelete,
+		BranchList,
+	},
+}
+
+var BranchCreate = cli.Command{
+	Name:      "create",
+	Usage:     "Create a branch in a repository.",
+	ArgsUsage: "<branch>",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "r",
+			Usage: "The repository to create the branch in.",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		ensureRepoFlag(c)
+		branch := ensureBranchArg(c, "Enter name of the branch to create")
+
+		req := createRepoRequest(c, "repo/branch/create", map[string]interface{}{
+			"repositoryId": c.String("r"),
+			"branch":       branch,
+		})
+		res := common.SendRequest(c, req, fmt.Sprintf("Creating branch '%s'", branch))
+
+		var result BranchResponse
+		common.ParseResponse(res, &result)
+		fmt.Fprintf(os.Stderr, "Created branch '%s' in repository '%s'\n", branch, c.String("r"))
+		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+
+		return nil
+	},
+}
+
+var BranchDelete = cli.Command{
+	Name:      "delete",
+	Aliases:   []string{"del"},
+	Usage:     "Delete a branch of a repository with all its nodes.",
+	ArgsUsage: "<branch>",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "r",
+			Usage: "The repository to delete the branch from.",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		ensureRepoFlag(c)
+		repoId := c.String("r")
+		branch := ensureBranchArg(c, "Enter name of the branch to delete")
+		if branch == MASTER_BRANCH {
+			fmt.Fprintf(os.Stderr, "Branch '%s' can not be deleted, delete the repository instead\n", MASTER_BRANCH)
+			os.Exit(1)
+		}
+		if isSystemRepo(repoId) {
+			fmt.Fprintf(os.Stderr, "Branches of system repository '%s' can not be deleted\n", repoId)
+			os.Exit(1)
+		}
+
+		if !common.IsForceMode(c) && !util.PromptBool(fmt.Sprintf("Delete branch '%s' of repository '%s' with all its nodes", branch, repoId), false) {
+			os.Exit(1)
+		}
+
+		req := createRepoRequest(c, "repo/branch/delete", map[string]interface{}{
+			"repositoryId": repoId,
+			"branch":       branch,
+		})
+		res := common.SendRequest(c, req, fmt.Sprintf("Deleting branch '%s'", branch))
+
+		var result BranchResponse
+		common.ParseResponse(res, &result)
+		fmt.Fprintf(os.Stderr, "Deleted branch '%s' of repository '%s'\n", branch, repoId)
+		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+
+		return nil
+	},
+}
+
+var BranchList = cli.Command{
+	Name:    "list",
+	Aliases: []string{"ls"},
+	Usage:   "List branches of a repository with their node counts.",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "r",
+			Usage: "The repository to list branches of.",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the branches as JSON",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		ensureRepoFlag(c)
+		details := fetchRepository(c, c.String("r"))
+
+		if c.Bool("json") {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(details.Branches))
+		} else {
+			printBranches(os.Stdout, details.Branches)
+		}
+
+		return nil
+	},
+}
+
+func ensureBranchArg(c *cli.Context, message string) string {
+	if common.IsForceMode(c) && c.Args().First() == "" {
+		fmt.Fprintln(os.Stderr, "Branch name can not be empty in non-interactive mode.")
+		os.Exit(1)
+	}
+	validator := func(val interface{}) error {
+		return validateBranchName(strings.TrimSpace(val.(string)))
+	}
+	return strings.TrimSpace(util.PromptString(message, c.Args().First(), "", validator))
+}
+
+type BranchResponse struct {
+	RepositoryId string `json:"repositoryId"`
+	Branch       string `json:"branch"`
+}
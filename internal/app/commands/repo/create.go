@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"bytes"
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var Create = cli.Command{
+	Name:      "create",
+	Usage:     "Create a repository.",
+	ArgsUsage: "<repo id>",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "settings",
+			Usage: "JSON file with the index definitions of the repository, keyed by index type like search or version",
+		},
+		cli.StringFlag{
+			Name:  "root-permissions",
+			Usage: "JSON file with the access control entries of the root node",
+		},
+		cli.StringFlag{
+			Name:  "root-child-order",
+			Usage: "Child order of the root node, e.g. '_ts DESC'",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		repoId := ensureRepoIdArg(c, "Enter id of the repository to create")
+		if isSystemRepo(repoId) {
+			fmt.Fprintf(os.Stderr, "Repository id '%s' is reserved for system repositories\n", repoId)
+			os.Exit(1)
+		}
+
+		params, err := createRepoParams(repoId, c.String("settings"), c.String("root-permissions"), c.String("root-child-order"))
+		util.Fatal(err, "Could not create repository:")
+
+		req := createRepoRequest(c, "repo/create", params)
+		res := common.SendRequest(c, req, fmt.Sprintf("Creating repository '%s'", repoId))
+
+		var result RepositoryDetails
+		common.ParseResponse(res, &result)
+		fmt.Fprintf(os.Stderr, "Created repository '%s'\n", repoId)
+		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+
+		return nil
+	},
+}
+
+func createRepoParams(repoId, settingsFile, permissionsFile, childOrder string) (map[string]interface{}, error) {
+	params := map[string]interface{}{
+		"repositoryId": repoId,
+	}
+	if settingsFile != "" {
+		var settings map[string]interface{}
+		if err := readJsonFile(settingsFile, &settings); err != nil {
+			return nil, err
+		}
+		params["indexDefinitions"] = settings
+	}
+	if permissionsFile != "" {
+		var permissions []interface{}
+		if err := readJsonFile(permissionsFile, &permissions); err != nil {
+			return nil, err
+		}
+		params["rootPermissions"] = permissions
+	}
+	if childOrder != "" {
+		params["rootChildOrder"] = childOrder
+	}
+	return params, nil
+}
+
+func readJsonFile(path string, target interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, target); err != nil {
+		return errors.Wrapf(err, "invalid JSON in '%s'", path)
+	}
+	return nil
+}
+
+func createRepoRequest(c *cli.Context, url string, params map[string]interface{}) *http.Request {
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(params)
+
+	return common.CreateRequest(c, "POST", url, body)
+}
@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var Delete = cli.Command{
+	Name:      "delete",
+	Aliases:   []string{"del"},
+	Usage:     "Delete a repository with all its branches and nodes.",
+	ArgsUsage: "<repo id>",
+	Flags:     append([]cli.Flag{common.FORCE_FLAG}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		repoId := ensureRepoIdArg(c, "Enter id of the repository to delete")
+		if isSystemRepo(repoId) {
+			fmt.Fprintf(os.Stderr, "Repository '%s' is a system repository and can not be deleted\n", repoId)
+			os.Exit(1)
+		}
+
+		details := fetchRepository(c, repoId)
+		if !common.IsForceMode(c) {
+			printRepositoryDetails(os.Stderr, details)
+			fmt.Fprintln(os.Stderr)
+			if !util.PromptBool(fmt.Sprintf("Delete repository '%s' with all its branches and nodes", repoId), false) {
+				os.Exit(1)
+			}
+			util.PromptString("Type the repository id to confirm", "", "", confirmRepoIdValidator(repoId))
+		}
+
+		req := createRepoRequest(c, "repo/delete", map[string]interface{}{
+			"repositoryId": repoId,
+		})
+		res := common.SendRequest(c, req, fmt.Sprintf("Deleting repository '%s'", repoId))
+
+		var result DeleteRepoResponse
+		common.ParseResponse(res, &result)
+		fmt.Fprintf(os.Stderr, "Deleted repository '%s'\n", repoId)
+		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
+
+		return nil
+	},
+}
+
+// confirmRepoIdValidator accepts only the repository id, so that a repository is not deleted by a mistyped command
+func confirmRepoIdValidator(repoId string) func(val interface{}) error {
+	return func(val interface{}) error {
+		if strings.TrimSpace(val.(string)) != repoId {
+			return errors.Errorf("Does not match '%s', type the repository id to delete it: ", repoId)
+		}
+		return nil
+	}
+}
+
+type DeleteRepoResponse struct {
+	RepositoryId string `json:"repositoryId"`
+}
@@ -0,0 +1,248 @@
+package repo
+
+import (
+	"bytes"
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const INDEX_SETTINGS_PREFIX = "index."
+
+var refreshIntervalPattern = regexp.MustCompile(`^(-1|\d+(ms|s|m|h)?)$`)
+var autoExpandPattern = regexp.MustCompile(`^(false|\d+-(\d+|all))$`)
+
+// index settings that can be changed on open indices, with the validation of their values
+var indexSettingParsers = map[string]func(value string) (interface{}, error){
+	"number_of_replicas": func(value string) (interface{}, error) {
+		num, err := strconv.Atoi(value)
+		if err != nil || num < 0 || num > 99 {
+			return nil, errors.Errorf("use whole numbers from 0 to 99")
+		}
+		return num, nil
+	},
+	"auto_expand_replicas": func(value string) (interface{}, error) {
+		if !autoExpandPattern.MatchString(value) {
+			return nil, errors.Errorf("use a range like 0-5 or 0-all, or false")
+		}
+		return value, nil
+	},
+	"refresh_interval": func(value string) (interface{}, error) {
+		if !refreshIntervalPattern.MatchString(value) {
+			return nil, errors.Errorf("use a duration like 1s or 500ms, or -1 to disable refresh")
+		}
+		return value, nil
+	},
+	"max_result_window": func(value string) (interface{}, error) {
+		num, err := strconv.Atoi(value)
+		if err != nil || num < 1 {
+			return nil, errors.Errorf("use a whole number above 0")
+		}
+		return num, nil
+	},
+	"blocks.write":     parseBoolSetting,
+	"blocks.read":      parseBoolSetting,
+	"blocks.read_only": parseBoolSetting,
+	"blocks.metadata":  parseBoolSetting,
+}
+
+var Index = cli.Command{
+	Name:  "index",
+	Usage: "Inspect and tune repository indices.",
+	Subcommands: []cli.Command{
+		{
+			Name:  "settings",
+			Usage: "Get or set index settings of repositories.",
+			Subcommands: []cli.Command{
+				SettingsGet,
+				SettingsSet,
+			},
+		},
+	},
+}
+
+var SettingsGet = cli.Command{
+	Name:  "get",
+	Usage: "Show replicas, write blocks and other index settings per repository.",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "r",
+			Usage: "Single repository to show index settings of",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the settings as JSON",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		settings := fetchIndexSettings(c, c.String("r"))
+
+		if c.Bool("json") {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(settings))
+		} else {
+			printIndexSettings(os.Stdout, settings)
+		}
+
+		return nil
+	},
+}
+
+var SettingsSet = cli.Command{
+	Name:      "set",
+	Usage:     "Change index settings of all repositories or a single one and show the difference.",
+	ArgsUsage: "<key=value>...",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "r",
+			Usage: "Single repository to change index settings of",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		changes, err := parseIndexSettings(c.Args())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		repoId := c.String("r")
+		before := fetchIndexSettings(c, repoId)
+
+		req := createUpdateSettingsRequest(c, repoId, changes)
+		res := common.SendRequest(c, req, "Updating index settings")
+		var result UpdateSettingsResponse
+		common.ParseResponse(res, &result)
+
+		after := fetchIndexSettings(c, repoId)
+		diff := util.UnifiedDiff("before", "after", formatIndexSettings(before), formatIndexSettings(after))
+		if diff == "" {
+			fmt.Fprintln(os.Stderr, "Index settings are unchanged")
+		} else {
+			fmt.Fprint(os.Stdout, diff)
+		}
+		fmt.Fprintf(os.Stderr, "Updated %d indices\n", len(result.UpdatedIndexes))
+
+		return nil
+	},
+}
+
+// parseIndexSettings validates key=value arguments, keys can be given with or without the index. prefix
+func parseIndexSettings(args []string) (map[string]interface{}, error) {
+	if len(args) == 0 {
+		return nil, errors.New("Give at least one setting to change as key=value, e.g. number_of_replicas=1")
+	}
+	settings := make(map[string]interface{})
+	for _, arg := range args {
+		key, value, found := strings.Cut(arg, "=")
+		key = strings.TrimPrefix(strings.TrimSpace(key), INDEX_SETTINGS_PREFIX)
+		value = strings.TrimSpace(value)
+		if !found || key == "" || value == "" {
+			return nil, errors.Errorf("Not a valid setting '%s', use key=value", arg)
+		}
+		parser, ok := indexSettingParsers[key]
+		if !ok {
+			return nil, errors.Errorf("Unknown index setting '%s', use one of: %s", key, strings.Join(slices.Sorted(maps.Keys(indexSettingParsers)), ", "))
+		}
+		parsed, err := parser(value)
+		if err != nil {
+			return nil, errors.Errorf("Not a valid value '%s' of %s, %s", value, key, err)
+		}
+		settings[key] = parsed
+	}
+	return settings, nil
+}
+
+func parseBoolSetting(value string) (interface{}, error) {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, errors.Errorf("use true or false")
+	}
+	return parsed, nil
+}
+
+func fetchIndexSettings(c *cli.Context, repoId string) *IndexSettingsResponse {
+	settingsUrl := "repo/index/settings"
+	if repoId != "" {
+		settingsUrl += "?repositoryId=" + url.QueryEscape(repoId)
+	}
+	req := common.CreateRequest(c, "GET", settingsUrl, nil)
+	res := common.SendRequest(c, req, "Loading index settings")
+
+	var result IndexSettingsResponse
+	common.ParseResponse(res, &result)
+	return &result
+}
+
+func createUpdateSettingsRequest(c *cli.Context, repoId string, settings map[string]interface{}) *http.Request {
+	body := new(bytes.Buffer)
+	params := map[string]interface{}{
+		"requireClosedIndex": false,
+		"settings": map[string]interface{}{
+			"index": settings,
+		},
+	}
+	if repoId != "" {
+		params["repositoryId"] = repoId
+	}
+	json.NewEncoder(body).Encode(params)
+
+	return common.CreateRequest(c, "POST", "repo/index/updateSettings", body)
+}
+
+func printIndexSettings(out io.Writer, settings *IndexSettingsResponse) {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, "REPOSITORY\tINDEX\tSETTING\tVALUE")
+	for _, repo := range settings.Repositories {
+		for _, index := range slices.Sorted(maps.Keys(repo.IndexSettings)) {
+			flat := flattenSettings("", repo.IndexSettings[index])
+			for _, key := range slices.Sorted(maps.Keys(flat)) {
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%v\n", repo.Id, index, key, flat[key])
+			}
+		}
+	}
+	writer.Flush()
+}
+
+// formatIndexSettings prints one setting per line for diffing
+func formatIndexSettings(settings *IndexSettingsResponse) string {
+	var out strings.Builder
+	for _, repo := range settings.Repositories {
+		for _, index := range slices.Sorted(maps.Keys(repo.IndexSettings)) {
+			flat := flattenSettings("", repo.IndexSettings[index])
+			for _, key := range slices.Sorted(maps.Keys(flat)) {
+				fmt.Fprintf(&out, "%s %s %s = %v\n", repo.Id, index, key, flat[key])
+			}
+		}
+	}
+	return out.String()
+}
+
+type IndexSettingsResponse struct {
+	Repositories []RepositoryIndexSettings `json:"repositories"`
+}
+
+type RepositoryIndexSettings struct {
+	Id            string                            `json:"id"`
+	IndexSettings map[string]map[string]interface{} `json:"indexSettings"`
+}
+
+type UpdateSettingsResponse struct {
+	UpdatedIndexes []string `json:"updatedIndexes"`
+}
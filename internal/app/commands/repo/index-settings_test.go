@@ -0,0 +1,53 @@
+package repo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseIndexSettings(t *testing.T) {
+	settings, err := parseIndexSettings([]string{"index.number_of_replicas=2", "blocks.write = true", "refresh_interval=500ms"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if settings["number_of_replicas"] != 2 || settings["blocks.write"] != true || settings["refresh_interval"] != "500ms" {
+		t.Errorf("unexpected settings %v", settings)
+	}
+
+	for arg, expected := range map[string]string{
+		"number_of_replicas=100": "0 to 99",
+		"blocks.write=yes":       "true or false",
+		"number_of_shards=3":     "Unknown index setting",
+		"refresh_interval":       "use key=value",
+		"auto_expand_replicas=1": "0-all",
+	} {
+		if _, err = parseIndexSettings([]string{arg}); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected error containing %q, got %v", arg, expected, err)
+		}
+	}
+	if _, err = parseIndexSettings(nil); err == nil {
+		t.Error("expected an error without settings")
+	}
+}
+
+func TestFormatIndexSettings(t *testing.T) {
+	settings := &IndexSettingsResponse{Repositories: []RepositoryIndexSettings{{
+		Id: "my-repo",
+		IndexSettings: map[string]map[string]interface{}{
+			"storage-my-repo": {"index": map[string]interface{}{"number_of_replicas": "1"}},
+			"search-my-repo":  {"index": map[string]interface{}{"number_of_replicas": "1", "blocks": map[string]interface{}{"write": "false"}}},
+		},
+	}}}
+
+	want := "my-repo search-my-repo index.blocks.write = false\nmy-repo search-my-repo index.number_of_replicas = 1\nmy-repo storage-my-repo index.number_of_replicas = 1\n"
+	if got := formatIndexSettings(settings); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	var out bytes.Buffer
+	printIndexSettings(&out, settings)
+	if !strings.HasPrefix(out.String(), "REPOSITORY   INDEX             SETTING                    VALUE\nmy-repo      search-my-repo    index.blocks.write         false\n") {
+		t.Errorf("unexpected table:\n%s", out.String())
+	}
+}
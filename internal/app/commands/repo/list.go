@@ -15,11 +15,7 @@ var List = cli.Command{
 	Flags:   append([]cli.Flag{common.FORCE_FLAG}, common.AUTH_AND_TLS_FLAGS...),
 	Action: func(c *cli.Context) error {
 
-		req := common.CreateRequest(c, "GET", "repo/list", nil)
-		res := common.SendRequest(c, req, "Loading")
-
-		var result RepositoriesResult
-		common.ParseResponse(res, &result)
+		result := fetchRepositories(c)
 
 		fmt.Fprintln(os.Stderr, "Done")
 		fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(result))
@@ -28,9 +24,25 @@ var List = cli.Command{
 	},
 }
 
-type RepositoriesResult struct {
-	Repositories []struct {
-		Branches []string
-		Id       string
+func fetchRepositories(c *cli.Context) *RepositoriesResult {
+	req := common.CreateRequest(c, "GET", "repo/list", nil)
+	res := common.SendRequest(c, req, "Loading")
+
+	if user, pass, ok := res.Request.BasicAuth(); ok {
+		// save the auth for the requests that follow
+		c.Set("auth", fmt.Sprintf("%s:%s", user, pass))
 	}
+
+	var result RepositoriesResult
+	common.ParseResponse(res, &result)
+	return &result
+}
+
+type RepositoriesResult struct {
+	Repositories []Repository
+}
+
+type Repository struct {
+	Branches []string
+	Id       string
 }
@@ -0,0 +1,282 @@
+package repo
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/commands/remote"
+	"cli-enonic/internal/app/util"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"gopkg.in/cheggaaa/pb.v1"
+)
+
+const REINDEX_STATE_FILE = "reindex-all.json"
+
+// reindexAll reindexes every branch of every repository, one task per repository. Finished repositories
+// are saved in a state file, so that a run that was interrupted or failed continues where it stopped.
+func reindexAll(c *cli.Context) {
+	if c.String("r") != "" || c.String("b") != "" {
+		fmt.Fprintln(os.Stderr, "--all reindexes every repository and branch, it can not be used with -r or -b")
+		os.Exit(1)
+	}
+	parallel := c.Int("parallel")
+	if parallel < 1 {
+		fmt.Fprintf(os.Stderr, "Not a valid --parallel value %d, use 1 or more\n", parallel)
+		os.Exit(1)
+	}
+
+	if parallel > 1 {
+		// the credentials are checked by listing the repositories below, before any worker sends a request
+		common.EnsureAuthFlag(c)
+	}
+	repos, excluded := filterRepositories(fetchRepositories(c).Repositories, common.SplitList(c.String("exclude")))
+	if len(excluded) > 0 {
+		fmt.Fprintf(os.Stderr, "Excluding %s\n", strings.Join(excluded, ", "))
+	}
+
+	statePath := common.GetInEnonicDir(REINDEX_STATE_FILE)
+	state := ensureReindexState(c, statePath)
+	pending := state.pending(repos)
+	if done := len(repos) - len(pending); done > 0 {
+		fmt.Fprintf(os.Stderr, "Resuming, %d of %d repositories are reindexed already\n", done, len(repos))
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(os.Stderr, "No repositories to reindex")
+		os.Remove(statePath)
+		return
+	}
+
+	var mutex sync.Mutex
+	results := runReindexTasks(c, pending, parallel, func(result *ReindexAllResult) {
+		if result.State != common.TASK_FINISHED {
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		state.Done = append(state.Done, result.RepositoryId)
+		util.Fatal(state.save(statePath), "Could not save reindex progress:")
+	})
+
+	var nodes uint32
+	failed := 0
+	for _, result := range results {
+		nodes += result.NumberReindexed
+		if result.State != common.TASK_FINISHED {
+			failed++
+			fmt.Fprintf(os.Stderr, "Failed to reindex '%s': %s\n", result.RepositoryId, result.Error)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Reindexed %d node(s) in %d repositories\n", nodes, len(results)-failed)
+	fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(results))
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d repositories failed, run the command again to retry them\n", failed)
+		os.Exit(1)
+	}
+	os.Remove(statePath)
+}
+
+// ensureReindexState loads the progress of a previous run against the same server, asking whether to resume it
+func ensureReindexState(c *cli.Context, statePath string) *ReindexState {
+	state := &ReindexState{
+		Remote:     remote.GetActiveRemote().Url.String(),
+		Initialize: c.Bool("i"),
+		StartTime:  time.Now(),
+		Done:       make([]string, 0),
+	}
+	if c.Bool("restart") {
+		return state
+	}
+
+	previous, err := loadReindexState(statePath)
+	if err != nil || previous == nil || previous.Remote != state.Remote || len(previous.Done) == 0 {
+		return state
+	}
+	if previous.Initialize != state.Initialize {
+		fmt.Fprintln(os.Stderr, "Previous reindex used a different -i option, starting over")
+		return state
+	}
+	message := fmt.Sprintf("Resume reindex started %s with %d repositories done", util.TimeFromNow(previous.StartTime), len(previous.Done))
+	if common.IsForceMode(c) || util.PromptBool(message, true) {
+		return previous
+	}
+	return state
+}
+
+func runReindexTasks(c *cli.Context, repos []Repository, parallel int, onDone func(*ReindexAllResult)) []*ReindexAllResult {
+	results := make([]*ReindexAllResult, len(repos))
+	if parallel == 1 {
+		for i, repo := range repos {
+			results[i] = reindexRepository(c, repo, startBar)
+			onDone(results[i])
+		}
+		return results
+	}
+
+	// the bars of the running tasks are drawn together, when there is no terminal to draw them in
+	// only the results are printed
+	newBar := func(label string) *pb.ProgressBar {
+		bar := common.NewTaskProgressBar(label)
+		bar.NotPrint = true
+		bar.Start()
+		return bar
+	}
+	pool, err := pb.StartPool()
+	if err == nil {
+		defer pool.Stop()
+		newBar = func(label string) *pb.ProgressBar {
+			bar := common.NewTaskProgressBar(label)
+			pool.Add(bar)
+			return bar
+		}
+	}
+
+	var wg sync.WaitGroup
+	queue := make(chan int)
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				results[i] = reindexRepository(c, repos[i], newBar)
+				if pool == nil {
+					fmt.Fprintf(os.Stderr, "Reindexing %s: %s\n", results[i].RepositoryId, strings.ToLower(results[i].State))
+				}
+				onDone(results[i])
+			}
+		}()
+	}
+	for i := range repos {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	return results
+}
+
+func startBar(label string) *pb.ProgressBar {
+	bar := common.NewTaskProgressBar(label)
+	bar.Start()
+	return bar
+}
+
+func reindexRepository(c *cli.Context, repo Repository, newBar func(label string) *pb.ProgressBar) *ReindexAllResult {
+	result := &ReindexAllResult{
+		RepositoryId: repo.Id,
+		Branches:     repo.Branches,
+		State:        common.TASK_FAILED,
+	}
+
+	taskId, err := startReindexTask(c, repo)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	var response ReindexResponse
+	status := common.DisplayTaskProgressBar(c, taskId, newBar(fmt.Sprintf("Reindexing %s", repo.Id)), &response)
+
+	result.State = status.State
+	result.NumberReindexed = response.NumberReindexed
+	result.Duration = response.Duration
+	if status.State != common.TASK_FINISHED {
+		result.Error = status.Progress.Info
+	}
+	return result
+}
+
+func startReindexTask(c *cli.Context, repo Repository) (string, error) {
+	req := newReindexRequest(c, "repo/index/reindexTask", repo.Id, strings.Join(repo.Branches, ","))
+	res, err := common.SendRequestCustom(c, req, "", 3)
+	if err != nil {
+		return "", err
+	}
+
+	var taskResult common.TaskResponse
+	enonicErr, err := common.ParseResponseCustom(res, &taskResult)
+	if enonicErr != nil {
+		return "", errors.Errorf("%d %s", enonicErr.Status, enonicErr.Message)
+	}
+	return taskResult.TaskId, err
+}
+
+// filterRepositories drops the excluded repositories and those without branches
+func filterRepositories(repos []Repository, excludes []string) ([]Repository, []string) {
+	kept := make([]Repository, 0, len(repos))
+	excluded := make([]string, 0)
+	for _, repo := range repos {
+		if isExcluded(repo.Id, excludes) {
+			excluded = append(excluded, repo.Id)
+		} else if len(repo.Branches) > 0 {
+			kept = append(kept, repo)
+		}
+	}
+	return kept, excluded
+}
+
+func isExcluded(repoId string, excludes []string) bool {
+	for _, pattern := range excludes {
+		if matched, err := path.Match(pattern, repoId); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func loadReindexState(statePath string) (*ReindexState, error) {
+	data, err := os.ReadFile(statePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var state ReindexState
+	if err = json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (s *ReindexState) save(statePath string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(statePath, data, 0644)
+}
+
+// pending returns the repositories that are not reindexed yet
+func (s *ReindexState) pending(repos []Repository) []Repository {
+	pending := make([]Repository, 0, len(repos))
+	for _, repo := range repos {
+		if !slices.Contains(s.Done, repo.Id) {
+			pending = append(pending, repo)
+		}
+	}
+	return pending
+}
+
+type ReindexState struct {
+	Remote     string    `json:"remote"`
+	Initialize bool      `json:"initialize"`
+	StartTime  time.Time `json:"startTime"`
+	Done       []string  `json:"done"`
+}
+
+type ReindexAllResult struct {
+	RepositoryId    string   `json:"repositoryId"`
+	Branches        []string `json:"branches"`
+	State           string   `json:"state"`
+	NumberReindexed uint32   `json:"numberReindexed"`
+	Duration        string   `json:"duration,omitempty"`
+	Error           string   `json:"error,omitempty"`
+}
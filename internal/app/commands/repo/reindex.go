@@ -31,10 +31,32 @@ var Reindex = cli.Command{
 			Name:  "i",
 			Usage: "If true, the indices will be deleted before recreated.",
 		},
+		cli.BoolFlag{
+			Name:  "all",
+			Usage: "Reindex all branches of all repositories, resuming a previous run that did not finish.",
+		},
+		cli.IntFlag{
+			Name:  "parallel",
+			Usage: "Number of repositories to reindex at the same time with --all.",
+			Value: 1,
+		},
+		cli.StringFlag{
+			Name:  "exclude",
+			Usage: "A comma-separated list of repositories to skip with --all, '*' matches any characters, e.g. 'system.*'",
+		},
+		cli.BoolFlag{
+			Name:  "restart",
+			Usage: "Discard the progress of a previous --all run and reindex every repository.",
+		},
 		common.FORCE_FLAG,
 	}, common.AUTH_AND_TLS_FLAGS...),
 	Action: func(c *cli.Context) error {
 
+		if c.Bool("all") {
+			reindexAll(c)
+			return nil
+		}
+
 		var result ReindexResponse
 		requestLabel := "Reindexing"
 
@@ -131,10 +153,14 @@ func ensureBranchesFlag(c *cli.Context) {
 }
 
 func createReindexRequest(c *cli.Context, url string) *http.Request {
+	return newReindexRequest(c, url, c.String("r"), c.String("b"))
+}
+
+func newReindexRequest(c *cli.Context, url, repo, branches string) *http.Request {
 	body := new(bytes.Buffer)
 	params := map[string]interface{}{
-		"repository": c.String("r"),
-		"branches":   c.String("b"),
+		"repository": repo,
+		"branches":   branches,
 	}
 	if init := c.Bool("i"); init {
 		params["initialize"] = init
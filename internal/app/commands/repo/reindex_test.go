@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterRepositories(t *testing.T) {
+	repos := []Repository{
+		{Id: "system-repo", Branches: []string{"master"}},
+		{Id: "system.auditlog", Branches: []string{"master"}},
+		{Id: "com.enonic.cms.default", Branches: []string{"draft", "master"}},
+		{Id: "empty"},
+	}
+	kept, excluded := filterRepositories(repos, common.SplitList(" system.*, ,other"))
+
+	if len(kept) != 2 || kept[0].Id != "system-repo" || kept[1].Id != "com.enonic.cms.default" {
+		t.Errorf("unexpected repositories %v", kept)
+	}
+	if strings.Join(excluded, ",") != "system.auditlog" {
+		t.Errorf("unexpected excluded %v", excluded)
+	}
+}
+
+func TestReindexStateResume(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), REINDEX_STATE_FILE)
+	if state, err := loadReindexState(statePath); err != nil || state != nil {
+		t.Fatalf("expected no state, got %v %v", state, err)
+	}
+
+	state := &ReindexState{Remote: "http://localhost:4848", StartTime: time.Now(), Done: []string{"system-repo"}}
+	if err := state.save(statePath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	loaded, err := loadReindexState(statePath)
+	if err != nil || loaded == nil {
+		t.Fatalf("could not load state: %v", err)
+	}
+
+	pending := loaded.pending([]Repository{{Id: "system-repo"}, {Id: "com.enonic.cms.default"}})
+	if len(pending) != 1 || pending[0].Id != "com.enonic.cms.default" {
+		t.Errorf("unexpected pending repositories %v", pending)
+	}
+}
@@ -10,5 +10,10 @@ func All() []cli.Command {
 		ReadOnly,
 		Replicas,
 		List,
+		Show,
+		Create,
+		Delete,
+		Branch,
+		Index,
 	}
 }
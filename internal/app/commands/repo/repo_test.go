@@ -0,0 +1,93 @@
+package repo
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateRepoId(t *testing.T) {
+	for _, id := range []string{"com.enonic.cms.default", "my-repo", "repo_1"} {
+		if err := validateRepoId(id); err != nil {
+			t.Errorf("expected '%s' to be valid: %v", id, err)
+		}
+	}
+	for _, id := range []string{"", "MyRepo", ".repo", "my repo"} {
+		if err := validateRepoId(id); err == nil {
+			t.Errorf("expected '%s' to be invalid", id)
+		}
+	}
+	if err := validateBranchName("Draft-2"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateBranchName("draft/2"); err == nil {
+		t.Error("expected branch with a slash to be invalid")
+	}
+}
+
+func TestIsSystemRepo(t *testing.T) {
+	for id, want := range map[string]bool{
+		"system-repo":            true,
+		"system.auditlog":        true,
+		"system.scheduler":       true,
+		"com.enonic.cms.default": false,
+		"systems":                false,
+	} {
+		if got := isSystemRepo(id); got != want {
+			t.Errorf("isSystemRepo(%s) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestConfirmRepoIdValidator(t *testing.T) {
+	validator := confirmRepoIdValidator("my-repo")
+	if err := validator(" my-repo "); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validator("my-rep"); err == nil {
+		t.Error("expected a mismatch error")
+	}
+}
+
+func TestCreateRepoParams(t *testing.T) {
+	dir := t.TempDir()
+	settings := filepath.Join(dir, "settings.json")
+	os.WriteFile(settings, []byte(`{"search": {"settings": {"index": {"number_of_replicas": 1}}}}`), 0644)
+
+	params, err := createRepoParams("my-repo", settings, "", "_ts DESC")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params["repositoryId"] != "my-repo" || params["rootChildOrder"] != "_ts DESC" || params["indexDefinitions"] == nil {
+		t.Errorf("unexpected params %v", params)
+	}
+	if _, ok := params["rootPermissions"]; ok {
+		t.Error("expected no root permissions")
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{`), 0644)
+	if _, err = createRepoParams("my-repo", invalid, "", ""); err == nil || !strings.Contains(err.Error(), "invalid.json") {
+		t.Errorf("expected invalid JSON error, got %v", err)
+	}
+}
+
+func TestPrintRepositoryDetails(t *testing.T) {
+	details := &RepositoryDetails{
+		Id:       "my-repo",
+		Branches: []BranchDetails{{Name: "master", NodeCount: 12}, {Name: "draft", NodeCount: 15}},
+		IndexSettings: map[string]map[string]interface{}{
+			"search": {"index": map[string]interface{}{"number_of_replicas": 1, "blocks": map[string]interface{}{"write": false}}},
+		},
+	}
+	var out bytes.Buffer
+	printRepositoryDetails(&out, details)
+
+	for _, expected := range []string{"Repository: my-repo\n", "BRANCH   NODES\nmaster   12\ndraft    15\n", "search   index.blocks.write         false\n", "search   index.number_of_replicas   1\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("output misses %q:\n%s", expected, out.String())
+		}
+	}
+}
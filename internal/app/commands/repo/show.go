@@ -0,0 +1,160 @@
+package repo
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const SYSTEM_REPO = "system-repo"
+const SYSTEM_REPO_PREFIX = "system."
+const MASTER_BRANCH = "master"
+
+// same rules as XP applies to repository ids and branch names
+var repoIdPattern = regexp.MustCompile(`^[a-z0-9\-:][a-z0-9_\-.:]*$`)
+var branchPattern = regexp.MustCompile(`^[a-zA-Z0-9\-:][a-zA-Z0-9_\-.:]*$`)
+
+var Show = cli.Command{
+	Name:      "show",
+	Usage:     "Show branches, index settings and node counts of a repository.",
+	ArgsUsage: "<repo id>",
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the repository as JSON",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		repoId := ensureRepoIdArg(c, "Enter repository id")
+		details := fetchRepository(c, repoId)
+
+		if c.Bool("json") {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(details))
+		} else {
+			printRepositoryDetails(os.Stdout, details)
+		}
+
+		return nil
+	},
+}
+
+func fetchRepository(c *cli.Context, repoId string) *RepositoryDetails {
+	req := common.CreateRequest(c, "GET", "repo/get?repositoryId="+url.QueryEscape(repoId), nil)
+	res := common.SendRequest(c, req, "Loading repository")
+
+	var details RepositoryDetails
+	common.ParseResponse(res, &details)
+	return &details
+}
+
+// ensureRepoIdArg returns the repository id from the first argument, prompting for it when it is missing
+func ensureRepoIdArg(c *cli.Context, message string) string {
+	if common.IsForceMode(c) && c.Args().First() == "" {
+		fmt.Fprintln(os.Stderr, "Repository id can not be empty in non-interactive mode.")
+		os.Exit(1)
+	}
+	validator := func(val interface{}) error {
+		return validateRepoId(strings.TrimSpace(val.(string)))
+	}
+	return strings.TrimSpace(util.PromptString(message, c.Args().First(), "", validator))
+}
+
+func validateRepoId(repoId string) error {
+	if repoId == "" {
+		return errors.New("Repository id can not be empty: ")
+	}
+	if !repoIdPattern.MatchString(repoId) {
+		return errors.Errorf("Not a valid repository id '%s'. Use lowercase letters, digits and '-', '_', '.', ':': ", repoId)
+	}
+	return nil
+}
+
+func validateBranchName(branch string) error {
+	if branch == "" {
+		return errors.New("Branch name can not be empty: ")
+	}
+	if !branchPattern.MatchString(branch) {
+		return errors.Errorf("Not a valid branch name '%s'. Use letters, digits and '-', '_', '.', ':': ", branch)
+	}
+	return nil
+}
+
+// isSystemRepo tells if the repository belongs to XP itself, like system-repo or system.auditlog
+func isSystemRepo(repoId string) bool {
+	return repoId == SYSTEM_REPO || strings.HasPrefix(repoId, SYSTEM_REPO_PREFIX)
+}
+
+func printRepositoryDetails(out io.Writer, details *RepositoryDetails) {
+	fmt.Fprintf(out, "Repository: %s\n", details.Id)
+	if details.Transient {
+		fmt.Fprintln(out, "Transient:  true")
+	}
+
+	fmt.Fprintln(out)
+	printBranches(out, details.Branches)
+
+	if len(details.IndexSettings) > 0 {
+		fmt.Fprintln(out)
+		writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(writer, "INDEX\tSETTING\tVALUE")
+		for _, index := range slices.Sorted(maps.Keys(details.IndexSettings)) {
+			settings := flattenSettings("", details.IndexSettings[index])
+			for _, key := range slices.Sorted(maps.Keys(settings)) {
+				fmt.Fprintf(writer, "%s\t%s\t%v\n", index, key, settings[key])
+			}
+		}
+		writer.Flush()
+	}
+}
+
+func printBranches(out io.Writer, branches []BranchDetails) {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, "BRANCH\tNODES")
+	for _, branch := range branches {
+		fmt.Fprintf(writer, "%s\t%d\n", branch.Name, branch.NodeCount)
+	}
+	writer.Flush()
+}
+
+// flattenSettings turns nested settings into dotted keys, like index.number_of_replicas
+func flattenSettings(prefix string, settings map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	for key, value := range settings {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			for k, v := range flattenSettings(key, nested) {
+				flat[k] = v
+			}
+		} else {
+			flat[key] = value
+		}
+	}
+	return flat
+}
+
+type RepositoryDetails struct {
+	Id            string                            `json:"id"`
+	Transient     bool                              `json:"transient"`
+	Branches      []BranchDetails                   `json:"branches"`
+	IndexSettings map[string]map[string]interface{} `json:"indexSettings"`
+}
+
+type BranchDetails struct {
+	Name      string `json:"name"`
+	NodeCount int64  `json:"nodeCount"`
+}
@@ -308,6 +308,23 @@ func EnsureSandboxExists(c *cli.Context, options EnsureSandboxOptions) (*Sandbox
 	return selectSandboxes[selectIndex], false
 }
 
+// EnsureSandboxFlag returns the sandbox given in the common.SANDBOX_FLAG or an empty string if it is not set,
+// prompting to select another one if it does not exist
+func EnsureSandboxFlag(c *cli.Context) string {
+	name := c.String("sandbox")
+	if name == "" {
+		return ""
+	}
+	box, _ := EnsureSandboxExists(c, EnsureSandboxOptions{
+		Name:             name,
+		SelectBoxMessage: "Select sandbox",
+	})
+	if box == nil {
+		os.Exit(1)
+	}
+	return box.Name
+}
+
 func CopyHomeFolder(distroPath, sandboxName string) {
 	targetHome := GetSandboxHomePath(sandboxName)
 	if _, err := os.Stat(targetHome); err == nil {
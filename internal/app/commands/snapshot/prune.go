@@ -0,0 +1,223 @@
+package snapshot
+
+import (
+	"bytes"
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const PRUNE_KEEP = "keep"
+const PRUNE_DELETE = "delete"
+const SNAPSHOT_DATE_FORMAT = "2006-01-02 15:04"
+
+var Prune = cli.Command{
+	Name:  "prune",
+	Usage: "Delete snapshots outside of a grandfather-father-son retention policy.",
+	Flags: append([]cli.Flag{
+		cli.IntFlag{
+			Name:  "keep-last",
+			Usage: "Keep this number of the most recent snapshots",
+		},
+		cli.IntFlag{
+			Name:  "keep-daily",
+			Usage: "Keep the most recent snapshot of this number of last days with snapshots",
+		},
+		cli.IntFlag{
+			Name:  "keep-weekly",
+			Usage: "Keep the most recent snapshot of this number of last weeks with snapshots",
+		},
+		cli.IntFlag{
+			Name:  "keep-monthly",
+			Usage: "Keep the most recent snapshot of this number of last months with snapshots",
+		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Only show which snapshots would be deleted",
+		},
+		common.FORCE_FLAG,
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		policy := RetentionPolicy{
+			KeepLast:    c.Int("keep-last"),
+			KeepDaily:   c.Int("keep-daily"),
+			KeepWeekly:  c.Int("keep-weekly"),
+			KeepMonthly: c.Int("keep-monthly"),
+		}
+		util.Fatal(policy.validate(), "Invalid argument:")
+
+		plan := planSnapshotPrune(listSnapshots(c).Results, policy)
+		printSnapshotPrunePlan(os.Stdout, plan)
+
+		toDelete := make([]string, 0)
+		for _, entry := range plan {
+			if entry.Action == PRUNE_DELETE {
+				toDelete = append(toDelete, entry.Snapshot.Name)
+			}
+		}
+		if len(toDelete) == 0 {
+			fmt.Fprintln(os.Stderr, "Nothing to delete")
+			return nil
+		}
+		if c.Bool("dry-run") {
+			fmt.Fprintf(os.Stderr, "Dry run, %d snapshot(s) would be deleted\n", len(toDelete))
+			return nil
+		}
+		if !common.IsForceMode(c) && !util.PromptBool(fmt.Sprintf("Delete %d snapshot(s)", len(toDelete)), false) {
+			return nil
+		}
+
+		result := deleteSnapshots(c, toDelete)
+		failed := printSnapshotDeleteResults(os.Stderr, toDelete, result)
+		if failed > 0 {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func (p RetentionPolicy) validate() error {
+	for _, count := range []int{p.KeepLast, p.KeepDaily, p.KeepWeekly, p.KeepMonthly} {
+		if count < 0 {
+			return errors.New("keep values can not be negative")
+		}
+	}
+	if p.KeepLast+p.KeepDaily+p.KeepWeekly+p.KeepMonthly == 0 {
+		return errors.New("at least one of --keep-last, --keep-daily, --keep-weekly or --keep-monthly is required")
+	}
+	return nil
+}
+
+// planSnapshotPrune keeps the most recent snapshots and the most recent snapshot of each of the last days,
+// weeks and months that have snapshots, newest first. Periods are in local time, weeks are ISO weeks.
+// Only successful snapshots count, snapshots in progress are always kept and failed ones are deleted.
+func planSnapshotPrune(snapshots []Snapshot, policy RetentionPolicy) []SnapshotPrunePlanEntry {
+	sorted := make([]Snapshot, len(snapshots))
+	copy(sorted, snapshots)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.After(sorted[j].Timestamp)
+	})
+
+	plan := make([]SnapshotPrunePlanEntry, len(sorted))
+	successful := make([]*SnapshotPrunePlanEntry, 0, len(sorted))
+	for i, snapshot := range sorted {
+		plan[i] = SnapshotPrunePlanEntry{Snapshot: snapshot, Reasons: make([]string, 0)}
+		switch snapshot.State {
+		case STATE_IN_PROGRESS:
+			plan[i].Reasons = append(plan[i].Reasons, "in progress")
+		case STATE_SUCCESS:
+			if len(successful) < policy.KeepLast {
+				plan[i].Reasons = append(plan[i].Reasons, fmt.Sprintf("last %d", policy.KeepLast))
+			}
+			successful = append(successful, &plan[i])
+		}
+	}
+
+	periods := []struct {
+		name   string
+		count  int
+		period func(t time.Time) string
+	}{
+		{"daily", policy.KeepDaily, func(t time.Time) string {
+			return t.Format("2006-01-02")
+		}},
+		{"weekly", policy.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{"monthly", policy.KeepMonthly, func(t time.Time) string {
+			return t.Format("2006-01")
+		}},
+	}
+	for _, rule := range periods {
+		seen := make(map[string]bool)
+		for _, entry := range successful {
+			if len(seen) >= rule.count {
+				break
+			}
+			period := rule.period(entry.Snapshot.Timestamp.Local())
+			if !seen[period] {
+				seen[period] = true
+				entry.Reasons = append(entry.Reasons, rule.name)
+			}
+		}
+	}
+
+	for i := range plan {
+		if len(plan[i].Reasons) > 0 {
+			plan[i].Action = PRUNE_KEEP
+		} else {
+			plan[i].Action = PRUNE_DELETE
+		}
+	}
+	return plan
+}
+
+func deleteSnapshots(c *cli.Context, names []string) *DeleteResult {
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(map[string]interface{}{
+		"snapshotNames": names,
+	})
+	req := common.CreateRequest(c, "POST", "repo/snapshot/delete", body)
+	resp := common.SendRequest(c, req, "Deleting snapshot(s)")
+
+	var result DeleteResult
+	common.ParseResponse(resp, &result)
+	return &result
+}
+
+func printSnapshotPrunePlan(out io.Writer, plan []SnapshotPrunePlanEntry) {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, strings.Join([]string{"ACTION", "NAME", "DATE", "STATE", "REASON"}, "\t"))
+	for _, entry := range plan {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", entry.Action, entry.Snapshot.Name, entry.Snapshot.Timestamp.Local().Format(SNAPSHOT_DATE_FORMAT), entry.Snapshot.State, strings.Join(entry.Reasons, ", "))
+	}
+	writer.Flush()
+}
+
+// printSnapshotDeleteResults reports each requested snapshot as deleted or not and returns the number of failures
+func printSnapshotDeleteResults(out io.Writer, requested []string, result *DeleteResult) int {
+	deleted := make(map[string]bool)
+	for _, name := range result.DeletedSnapshots {
+		deleted[name] = true
+	}
+
+	failed := 0
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tRESULT")
+	for _, name := range requested {
+		if deleted[name] {
+			fmt.Fprintf(writer, "%s\tdeleted\n", name)
+		} else {
+			fmt.Fprintf(writer, "%s\tnot deleted\n", name)
+			failed++
+		}
+	}
+	writer.Flush()
+	fmt.Fprintf(out, "%d deleted, %d failed\n", len(requested)-failed, failed)
+	return failed
+}
+
+type RetentionPolicy struct {
+	KeepLast    int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+}
+
+type SnapshotPrunePlanEntry struct {
+	Snapshot Snapshot
+	Action   string
+	Reasons  []string
+}
@@ -0,0 +1,112 @@
+package snapshot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// snapshotsAt creates a snapshot at noon of each given day of 2024, named after the day of the year
+func snapshotsAt(dates ...string) []Snapshot {
+	snapshots := make([]Snapshot, len(dates))
+	for i, date := range dates {
+		day, _ := time.ParseInLocation("2006-01-02", date, time.Local)
+		snapshots[i] = Snapshot{Name: date, State: STATE_SUCCESS, Timestamp: day.Add(12 * time.Hour)}
+	}
+	return snapshots
+}
+
+func keptNames(plan []SnapshotPrunePlanEntry) string {
+	kept := make([]string, 0)
+	for _, entry := range plan {
+		if entry.Action == PRUNE_KEEP {
+			kept = append(kept, entry.Snapshot.Name)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+func TestPlanSnapshotPruneKeepLast(t *testing.T) {
+	plan := planSnapshotPrune(snapshotsAt("2024-03-01", "2024-03-03", "2024-03-02"), RetentionPolicy{KeepLast: 2})
+
+	if got := keptNames(plan); got != "2024-03-03 2024-03-02" {
+		t.Errorf("got %s", got)
+	}
+	if plan[2].Action != PRUNE_DELETE {
+		t.Errorf("oldest snapshot should be deleted, got %s", plan[2].Action)
+	}
+}
+
+func TestPlanSnapshotPruneDaily(t *testing.T) {
+	snapshots := snapshotsAt("2024-03-01", "2024-03-02", "2024-03-03", "2024-03-03")
+	snapshots[3].Name = "2024-03-03-late"
+	snapshots[3].Timestamp = snapshots[3].Timestamp.Add(time.Hour)
+
+	plan := planSnapshotPrune(snapshots, RetentionPolicy{KeepDaily: 2})
+	if got := keptNames(plan); got != "2024-03-03-late 2024-03-02" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestPlanSnapshotPruneGrandfatherFatherSon(t *testing.T) {
+	snapshots := snapshotsAt(
+		"2024-01-10", "2024-01-31",
+		"2024-02-14", "2024-02-28",
+		"2024-03-04", "2024-03-05", "2024-03-11", "2024-03-12", "2024-03-13",
+	)
+	plan := planSnapshotPrune(snapshots, RetentionPolicy{KeepLast: 1, KeepDaily: 2, KeepWeekly: 2, KeepMonthly: 3})
+
+	// weeks of 11 and 4 March are the last two, months are March, February and January
+	want := "2024-03-13 2024-03-12 2024-03-05 2024-02-28 2024-01-31"
+	if got := keptNames(plan); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got := strings.Join(plan[0].Reasons, ", "); got != "last 1, daily, weekly, monthly" {
+		t.Errorf("got reasons %s", got)
+	}
+}
+
+func TestPlanSnapshotPruneCountsOnlySuccessful(t *testing.T) {
+	snapshots := snapshotsAt("2024-03-01", "2024-03-02", "2024-03-03", "2024-03-04", "2024-03-05")
+	snapshots[2].State = "FAILED"
+	snapshots[3].State = "PARTIAL"
+	snapshots[4].State = STATE_IN_PROGRESS
+
+	plan := planSnapshotPrune(snapshots, RetentionPolicy{KeepLast: 1, KeepDaily: 2})
+
+	// the snapshot in progress is kept but does not count, the failed and partial days are skipped
+	if got := keptNames(plan); got != "2024-03-05 2024-03-02 2024-03-01" {
+		t.Errorf("got %s", got)
+	}
+	if got := strings.Join(plan[0].Reasons, ", "); got != "in progress" {
+		t.Errorf("got reasons %s for the snapshot in progress", got)
+	}
+	if got := strings.Join(plan[3].Reasons, ", "); got != "last 1, daily" {
+		t.Errorf("got reasons %s for the latest successful snapshot", got)
+	}
+}
+
+func TestRetentionPolicyValidate(t *testing.T) {
+	if err := (RetentionPolicy{}).validate(); err == nil {
+		t.Errorf("expected an error for an empty policy")
+	}
+	if err := (RetentionPolicy{KeepDaily: -1, KeepLast: 2}).validate(); err == nil {
+		t.Errorf("expected an error for a negative value")
+	}
+	if err := (RetentionPolicy{KeepWeekly: 4}).validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPrintSnapshotDeleteResults(t *testing.T) {
+	var out bytes.Buffer
+	failed := printSnapshotDeleteResults(&out, []string{"a", "b"}, &DeleteResult{DeletedSnapshots: []string{"a"}})
+
+	if failed != 1 {
+		t.Errorf("got %d failed, want 1", failed)
+	}
+	if !strings.Contains(out.String(), "not deleted") || !strings.Contains(out.String(), "1 deleted, 1 failed") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/urfave/cli"
+	"io"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
 )
 
@@ -32,13 +34,29 @@ var Restore = cli.Command{
 			Name:  "clean",
 			Usage: "Delete indices before restoring",
 		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Only show which repositories would be replaced",
+		},
 		common.FORCE_FLAG,
 	}, append(common.AUTH_AND_TLS_FLAGS, common.COMPAT_FLAG)...),
 	Action: func(c *cli.Context) error {
 
 		util.Fatal(common.ValidateCompatFlag(c), "Invalid argument")
 
-		req := createRestoreRequest(c)
+		snapshot := resolveRestoreSnapshot(c)
+		details := describeSnapshot(snapshot)
+		repos := ensureRestoreRepos(c, details)
+		printRestorePlan(os.Stderr, details, repos, c.Bool("clean"))
+		if c.Bool("dry-run") {
+			return nil
+		}
+		if !common.IsForceMode(c) && !util.PromptBool("Replace the repositories with the snapshot", false) {
+			return nil
+		}
+
+		// the snapshot shown in the plan is restored, even if a newer one was made since
+		req := createRestoreRequest(c, snapshot.Name)
 		var result RestoreResult
 
 		if common.IsCompatMode(c) {
@@ -77,44 +95,79 @@ var Restore = cli.Command{
 	},
 }
 
-func ensureSnapshotFlag(c *cli.Context) string {
-	return ensureSnapshotFlagWithMessage(c, "Select snapshot to restore")
-}
-
 func ensureSnapshotFlagWithMessage(c *cli.Context, message string) string {
 	snapName := c.String("snapshot")
 	if strings.TrimSpace(snapName) != "" {
 		return snapName
 	}
 
-	if common.IsForceMode(c) {
-		fmt.Fprintln(os.Stderr, "Snapshot name can not be empty in non-interactive mode.")
+	return selectSnapshot(c, listSnapshots(c), message).Name
+}
+
+// resolveRestoreSnapshot finds the snapshot that is going to be restored, the latest one when --latest is set
+func resolveRestoreSnapshot(c *cli.Context) *Snapshot {
+	list := listSnapshots(c)
+	if !c.Bool("latest") {
+		return ensureSnapshotInList(c, list, "Select snapshot to restore")
+	}
+	latest := latestSnapshot(list)
+	if latest == nil {
+		fmt.Fprintln(os.Stderr, "No successful snapshots found")
 		os.Exit(1)
 	}
+	return latest
+}
 
-	snapshotList := listSnapshots(c)
-	if len(snapshotList.Results) == 0 {
-		fmt.Fprintln(os.Stderr, "No existing snapshots found")
-		os.Exit(1)
+// ensureRestoreRepos returns the repositories that the restore replaces.
+// Without --repo all repositories of the snapshot are restored, unless a single one is picked interactively.
+func ensureRestoreRepos(c *cli.Context, details *SnapshotDetails) []string {
+	repos := details.repositoryNames()
+	if repo := c.String("repo"); repo != "" {
+		if len(repos) > 0 && !slices.Contains(repos, repo) {
+			fmt.Fprintf(os.Stderr, "Repository '%s' is not in snapshot '%s'\n", repo, details.Name)
+			os.Exit(1)
+		}
+		return []string{repo}
+	}
+	if common.IsForceMode(c) || len(repos) < 2 {
+		return repos
 	}
 
+	options := append([]string{fmt.Sprintf("All repositories (%d)", len(repos))}, repos...)
 	_, pos, err := util.PromptSelect(&util.SelectOptions{
-		Message: message,
-		Options: getSnapshotDisplayNames(snapshotList),
+		Message: "Select repositories to restore",
+		Options: options,
 	})
-	util.Fatal(err, "Could not select snapshot: ")
+	util.Fatal(err, "Could not select repository: ")
+	if pos == 0 {
+		return repos
+	}
+	c.Set("repo", repos[pos-1])
+	return []string{repos[pos-1]}
+}
 
-	return snapshotList.Results[pos].Name
+func printRestorePlan(out io.Writer, details *SnapshotDetails, repos []string, clean bool) {
+	date := ""
+	if !details.Timestamp.IsZero() {
+		date = " from " + details.Timestamp.Local().Format(SNAPSHOT_DATE_FORMAT)
+	}
+	if len(repos) == 0 {
+		fmt.Fprintf(out, "Snapshot \"%s\"%s lists no repositories, all repositories in it will be replaced\n", details.Name, date)
+		return
+	}
+	fmt.Fprintf(out, "Snapshot \"%s\"%s will replace %d repositories:\n", details.Name, date, len(repos))
+	for _, repo := range repos {
+		fmt.Fprintf(out, "  %s\n", repo)
+	}
+	if clean {
+		fmt.Fprintln(out, "Their indices are deleted before restoring.")
+	}
 }
 
-func createRestoreRequest(c *cli.Context) *http.Request {
+func createRestoreRequest(c *cli.Context, snapshotName string) *http.Request {
 	body := new(bytes.Buffer)
-	params := map[string]interface{}{}
-
-	if c.Bool("latest") {
-		params["latest"] = true
-	} else {
-		params["snapshotName"] = ensureSnapshotFlag(c)
+	params := map[string]interface{}{
+		"snapshotName": snapshotName,
 	}
 
 	if c.Bool("clean") {
@@ -35,21 +35,21 @@ func newRestoreCtx(compat, snap, repo string, latest, clean bool) *cli.Context {
 func TestCreateRestoreRequest_Latest(t *testing.T) {
 	isolateEnonicHome(t)
 	c := newRestoreCtx("", "", "", true, false)
-	req := createRestoreRequest(c)
+	req := createRestoreRequest(c, "shown-snap")
 	params := decodeJSONBody(t, req.Body)
 
-	if params["latest"] != true {
-		t.Errorf("expected latest=true, got %v", params["latest"])
+	if params["snapshotName"] != "shown-snap" {
+		t.Errorf("expected the shown snapshotName with --latest, got %v", params["snapshotName"])
 	}
-	if _, ok := params["snapshotName"]; ok {
-		t.Errorf("expected no snapshotName when --latest set, got %v", params["snapshotName"])
+	if _, ok := params["latest"]; ok {
+		t.Errorf("expected no latest, it could pick a snapshot made after the plan was shown")
 	}
 }
 
 func TestCreateRestoreRequest_BySnapshotName(t *testing.T) {
 	isolateEnonicHome(t)
 	c := newRestoreCtx("", "my-snap", "", false, false)
-	req := createRestoreRequest(c)
+	req := createRestoreRequest(c, "my-snap")
 	params := decodeJSONBody(t, req.Body)
 
 	if params["snapshotName"] != "my-snap" {
@@ -63,7 +63,7 @@ func TestCreateRestoreRequest_BySnapshotName(t *testing.T) {
 func TestCreateRestoreRequest_Clean(t *testing.T) {
 	isolateEnonicHome(t)
 	c := newRestoreCtx("", "my-snap", "", false, true)
-	req := createRestoreRequest(c)
+	req := createRestoreRequest(c, "my-snap")
 	params := decodeJSONBody(t, req.Body)
 
 	if params["force"] != true {
@@ -74,7 +74,7 @@ func TestCreateRestoreRequest_Clean(t *testing.T) {
 func TestCreateRestoreRequest_Repository(t *testing.T) {
 	isolateEnonicHome(t)
 	c := newRestoreCtx("", "my-snap", "my-repo", false, false)
-	req := createRestoreRequest(c)
+	req := createRestoreRequest(c, "my-snap")
 	params := decodeJSONBody(t, req.Body)
 
 	if params["repository"] != "my-repo" {
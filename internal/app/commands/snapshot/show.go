@@ -0,0 +1,194 @@
+package snapshot
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// XP keeps every repository in a search and a storage index named after it
+var repoIndexPrefixes = []string{"search-", "storage-"}
+
+var Show = cli.Command{
+	Name:      "show",
+	Usage:     "Show the repositories and indices contained in a snapshot.",
+	ArgsUsage: "<snapshot name>",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "snapshot, snap",
+			Usage: "The name of the snapshot to show",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the snapshot as JSON",
+		},
+	}, common.AUTH_AND_TLS_FLAGS...),
+	Action: func(c *cli.Context) error {
+
+		if c.NArg() > 0 {
+			c.Set("snapshot", c.Args().First())
+		}
+		list := listSnapshots(c)
+		snapshot := ensureSnapshotInList(c, list, "Select snapshot to show")
+		details := describeSnapshot(snapshot)
+
+		if c.Bool("json") {
+			fmt.Fprintln(os.Stdout, util.PrettyPrintJSON(details))
+		} else {
+			printSnapshotDetails(os.Stdout, details)
+		}
+
+		return nil
+	},
+}
+
+// ensureSnapshotInList returns the snapshot named in the flag, prompting to select one when the flag is empty
+func ensureSnapshotInList(c *cli.Context, list *SnapshotList, message string) *Snapshot {
+	name := strings.TrimSpace(c.String("snapshot"))
+	if name == "" {
+		return selectSnapshot(c, list, message)
+	}
+	snapshot := findSnapshot(list, name)
+	if snapshot == nil {
+		fmt.Fprintf(os.Stderr, "Snapshot '%s' not found\n", name)
+		os.Exit(1)
+	}
+	return snapshot
+}
+
+func selectSnapshot(c *cli.Context, list *SnapshotList, message string) *Snapshot {
+	if common.IsForceMode(c) {
+		fmt.Fprintln(os.Stderr, "Snapshot name can not be empty in non-interactive mode.")
+		os.Exit(1)
+	}
+	if len(list.Results) == 0 {
+		fmt.Fprintln(os.Stderr, "No existing snapshots found")
+		os.Exit(1)
+	}
+
+	_, pos, err := util.PromptSelect(&util.SelectOptions{
+		Message: message,
+		Options: getSnapshotDisplayNames(list),
+	})
+	util.Fatal(err, "Could not select snapshot: ")
+
+	c.Set("snapshot", list.Results[pos].Name)
+	return &list.Results[pos]
+}
+
+func findSnapshot(list *SnapshotList, name string) *Snapshot {
+	for i, snapshot := range list.Results {
+		if snapshot.Name == name {
+			return &list.Results[i]
+		}
+	}
+	return nil
+}
+
+// latestSnapshot returns the latest successful snapshot, failed and unfinished ones can not be restored
+func latestSnapshot(list *SnapshotList) *Snapshot {
+	var latest *Snapshot
+	for i, snapshot := range list.Results {
+		if snapshot.State != STATE_SUCCESS {
+			continue
+		}
+		if latest == nil || snapshot.Timestamp.After(latest.Timestamp) {
+			latest = &list.Results[i]
+		}
+	}
+	return latest
+}
+
+// describeSnapshot groups the snapshot indices by repository, indices not following the repository naming are kept aside
+func describeSnapshot(snapshot *Snapshot) *SnapshotDetails {
+	details := &SnapshotDetails{
+		Name:         snapshot.Name,
+		State:        snapshot.State,
+		Reason:       snapshot.Reason,
+		Timestamp:    snapshot.Timestamp,
+		Repositories: make([]SnapshotRepository, 0),
+		OtherIndices: make([]string, 0),
+	}
+
+	byRepo := make(map[string][]string)
+	for _, index := range snapshot.Indices {
+		repo := repoOfIndex(index)
+		if repo == "" {
+			details.OtherIndices = append(details.OtherIndices, index)
+		} else {
+			byRepo[repo] = append(byRepo[repo], index)
+		}
+	}
+	for repo, indices := range byRepo {
+		sort.Strings(indices)
+		details.Repositories = append(details.Repositories, SnapshotRepository{Name: repo, Indices: indices})
+	}
+	sort.Slice(details.Repositories, func(i, j int) bool {
+		return details.Repositories[i].Name < details.Repositories[j].Name
+	})
+	sort.Strings(details.OtherIndices)
+	return details
+}
+
+func repoOfIndex(index string) string {
+	for _, prefix := range repoIndexPrefixes {
+		if strings.HasPrefix(index, prefix) && len(index) > len(prefix) {
+			return index[len(prefix):]
+		}
+	}
+	return ""
+}
+
+func (d *SnapshotDetails) repositoryNames() []string {
+	names := make([]string, len(d.Repositories))
+	for i, repo := range d.Repositories {
+		names[i] = repo.Name
+	}
+	return names
+}
+
+func printSnapshotDetails(out io.Writer, details *SnapshotDetails) {
+	writer := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(writer, "Name:\t%s\n", details.Name)
+	fmt.Fprintf(writer, "State:\t%s\n", details.State)
+	if details.Reason != "" {
+		fmt.Fprintf(writer, "Reason:\t%s\n", details.Reason)
+	}
+	if !details.Timestamp.IsZero() {
+		fmt.Fprintf(writer, "Date:\t%s\n", details.Timestamp.Local().Format(SNAPSHOT_DATE_FORMAT))
+	}
+	writer.Flush()
+
+	fmt.Fprintln(out)
+	writer = tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, "REPOSITORY\tINDICES")
+	for _, repo := range details.Repositories {
+		fmt.Fprintf(writer, "%s\t%s\n", repo.Name, strings.Join(repo.Indices, ", "))
+	}
+	if len(details.OtherIndices) > 0 {
+		fmt.Fprintf(writer, "-\t%s\n", strings.Join(details.OtherIndices, ", "))
+	}
+	writer.Flush()
+}
+
+type SnapshotDetails struct {
+	Name         string               `json:"name"`
+	State        string               `json:"state"`
+	Reason       string               `json:"reason"`
+	Timestamp    time.Time            `json:"timestamp"`
+	Repositories []SnapshotRepository `json:"repositories"`
+	OtherIndices []string             `json:"otherIndices"`
+}
+
+type SnapshotRepository struct {
+	Name    string   `json:"name"`
+	Indices []string `json:"indices"`
+}
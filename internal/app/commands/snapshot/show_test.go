@@ -0,0 +1,68 @@
+package snapshot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDescribeSnapshot(t *testing.T) {
+	details := describeSnapshot(&Snapshot{
+		Name:  "snap-1",
+		State: "SUCCESS",
+		Indices: []string{
+			"storage-com.enonic.cms.default",
+			"search-system-repo",
+			"search-com.enonic.cms.default",
+			"storage-system-repo",
+			"custom-index",
+		},
+	})
+
+	if got := strings.Join(details.repositoryNames(), " "); got != "com.enonic.cms.default system-repo" {
+		t.Errorf("got repositories %s", got)
+	}
+	if got := strings.Join(details.Repositories[0].Indices, " "); got != "search-com.enonic.cms.default storage-com.enonic.cms.default" {
+		t.Errorf("got indices %s", got)
+	}
+	if len(details.OtherIndices) != 1 || details.OtherIndices[0] != "custom-index" {
+		t.Errorf("got other indices %v", details.OtherIndices)
+	}
+}
+
+func TestLatestSnapshot(t *testing.T) {
+	now := time.Now()
+	list := &SnapshotList{Results: []Snapshot{
+		{Name: "old", State: STATE_SUCCESS, Timestamp: now.Add(-time.Hour)},
+		{Name: "new", State: STATE_SUCCESS, Timestamp: now},
+		{Name: "older", State: STATE_SUCCESS, Timestamp: now.Add(-2 * time.Hour)},
+		{Name: "failed", State: "FAILED", Timestamp: now.Add(time.Minute)},
+		{Name: "running", State: STATE_IN_PROGRESS, Timestamp: now.Add(2 * time.Minute)},
+	}}
+	if got := latestSnapshot(list); got == nil || got.Name != "new" {
+		t.Errorf("got %v, want new", got)
+	}
+	if got := latestSnapshot(&SnapshotList{}); got != nil {
+		t.Errorf("got %v for an empty list", got)
+	}
+	if got := findSnapshot(list, "older"); got == nil || got.Name != "older" {
+		t.Errorf("got %v, want older", got)
+	}
+}
+
+func TestPrintRestorePlan(t *testing.T) {
+	details := &SnapshotDetails{Name: "snap-1"}
+
+	var out bytes.Buffer
+	printRestorePlan(&out, details, []string{"system-repo", "com.enonic.cms.default"}, true)
+	if !strings.Contains(out.String(), "will replace 2 repositories:\n  system-repo\n  com.enonic.cms.default\n") || !strings.Contains(out.String(), "deleted before restoring") {
+		t.Errorf("unexpected plan:\n%s", out.String())
+	}
+
+	out.Reset()
+	printRestorePlan(&out, details, nil, false)
+	if !strings.Contains(out.String(), "lists no repositories") {
+		t.Errorf("unexpected plan:\n%s", out.String())
+	}
+}
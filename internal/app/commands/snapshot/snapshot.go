@@ -8,12 +8,17 @@ import (
 func All() []cli.Command {
 	return []cli.Command{
 		List,
+		Show,
 		Create,
 		Restore,
 		Delete,
+		Prune,
 	}
 }
 
+const STATE_SUCCESS = "SUCCESS"
+const STATE_IN_PROGRESS = "IN_PROGRESS"
+
 type Snapshot struct {
 	Name      string    `json:"name"`
 	Reason    string    `json:"reason"`
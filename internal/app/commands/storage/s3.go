@@ -0,0 +1,610 @@
+package storage
+
+import (
+	"bytes"
+	"cli-enonic/internal/app/commands/common"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const S3_DEFAULT_REGION = "us-east-1"
+const S3_MIN_PART_SIZE = 5 * 1024 * 1024
+const S3_DEFAULT_PART_SIZE = 16 * 1024 * 1024
+const S3_MAX_PARTS = 10000
+const S3_CHECKSUM_HEADER = "X-Amz-Checksum-Sha256"
+const S3_CHECKSUM_TYPE_HEADER = "X-Amz-Checksum-Type"
+const S3_SHA256_META_HEADER = "X-Amz-Meta-Sha256"
+const S3_EMPTY_PAYLOAD_HASH = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+const S3_DATE_FORMAT = "20060102T150405Z"
+
+type s3Storage struct {
+	endpoint  *url.URL
+	bucket    string
+	pathStyle bool
+	region    string
+	accessKey string
+	secretKey string
+	partSize  int64
+	client    *http.Client
+	now       func() time.Time
+}
+
+// newS3Storage talks to AWS with virtual-hosted buckets unless an endpoint is configured,
+// other S3-compatible stores like MinIO are addressed with the bucket in the path
+func newS3Storage(location *url.URL, profile *Profile) (Storage, error) {
+	s := &s3Storage{
+		bucket:    location.Host,
+		region:    profile.Region,
+		accessKey: profile.AccessKey,
+		secretKey: profile.SecretKey,
+		partSize:  S3_DEFAULT_PART_SIZE,
+		client:    &http.Client{},
+		now:       time.Now,
+	}
+	if s.region == "" {
+		s.region = S3_DEFAULT_REGION
+	}
+	if profile.PartSizeMB > 0 {
+		s.partSize = int64(profile.PartSizeMB) * 1024 * 1024
+	}
+	if s.partSize < S3_MIN_PART_SIZE {
+		return nil, errors.Errorf("part size must be at least %d MB", S3_MIN_PART_SIZE/1024/1024)
+	}
+
+	if profile.Endpoint != "" {
+		endpoint, err := url.Parse(profile.Endpoint)
+		if err != nil || endpoint.Host == "" {
+			return nil, errors.Errorf("invalid storage endpoint '%s'", profile.Endpoint)
+		}
+		s.endpoint = endpoint
+		s.pathStyle = true
+	} else {
+		s.endpoint = &url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", s.bucket, s.region)}
+	}
+	return s, nil
+}
+
+func (s *s3Storage) Stat(key string) (*ObjectInfo, error) {
+	res, err := s.do("HEAD", key, nil, map[string]string{"X-Amz-Checksum-Mode": "ENABLED"}, nil)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return s3ObjectInfo(key, res), nil
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, errors.Errorf("could not read '%s' from bucket '%s': %s", key, s.bucket, res.Status)
+	}
+}
+
+func (s *s3Storage) Download(key, target string) (*ObjectInfo, error) {
+	info, err := s.Stat(key)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, errors.Errorf("'%s' does not exist in bucket '%s'", key, s.bucket)
+	}
+
+	partFile := target + common.PART_FILE_EXT
+	partUrl := fmt.Sprintf("s3://%s/%s", s.bucket, key)
+	var offset int64
+	if stat, err := os.Stat(partFile); err == nil {
+		// only a partial download of the same version of the object is continued
+		if part := common.ReadPartInfo(partFile); part != nil && part.Url == partUrl && part.ETag != "" && part.ETag == info.ETag &&
+			part.Size == info.Size && stat.Size() <= info.Size {
+			offset = stat.Size()
+		} else {
+			common.RemovePartFile(partFile)
+		}
+	}
+
+	if offset == 0 || offset < info.Size {
+		if err = s.downloadFrom(key, partFile, partUrl, offset, info); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = common.VerifyChecksum(partFile, info.Sha256); err != nil {
+		common.RemovePartFile(partFile)
+		return nil, err
+	}
+	os.Remove(partFile + common.PART_INFO_EXT)
+	return info, os.Rename(partFile, target)
+}
+
+// downloadFrom fetches the object from the offset on, as long as it is the version seen by Stat
+func (s *s3Storage) downloadFrom(key, partFile, partUrl string, offset int64, info *ObjectInfo) error {
+	headers := make(map[string]string)
+	if offset > 0 {
+		headers["Range"] = fmt.Sprintf("bytes=%d-", offset)
+	}
+	if info.ETag != "" {
+		headers["If-Match"] = info.ETag
+	}
+	res, err := s.do("GET", key, nil, headers, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if err = os.MkdirAll(filepath.Dir(partFile), 0755); err != nil {
+		return err
+	}
+	flags := os.O_CREATE | os.O_WRONLY
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		fmt.Fprintf(os.Stderr, "Resuming download at %d bytes\n", offset)
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		offset = 0
+		flags |= os.O_TRUNC
+		if err = common.WritePartInfo(partFile, &common.PartInfo{Url: partUrl, Size: info.Size, ETag: info.ETag, Checksum: info.Sha256}); err != nil {
+			return err
+		}
+	case http.StatusPreconditionFailed:
+		common.RemovePartFile(partFile)
+		return errors.Errorf("'%s' changed while downloading it, run the command again", key)
+	default:
+		return s3Error(res)
+	}
+
+	file, err := os.OpenFile(partFile, flags, 0640)
+	if err != nil {
+		return err
+	}
+
+	bar := common.NewTransferBar("Downloading", info.Size, offset)
+	_, err = io.Copy(file, bar.NewProxyReader(res.Body))
+	bar.Finish()
+	file.Close()
+	if err != nil {
+		return errors.Wrap(err, "download interrupted, run the command again to resume")
+	}
+	return nil
+}
+
+// Upload sends the file in parts with their checksums verified by the server. Parts of an unfinished upload
+// of the same key are reused when they match the file, otherwise that upload is aborted and started over.
+func (s *s3Storage) Upload(key, path string) (*ObjectInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := stat.Size()
+	checksum, err := common.FileChecksum(path)
+	if err != nil {
+		return nil, err
+	}
+	partSize := s.partSizeFor(size)
+	partCount := int((size + partSize - 1) / partSize)
+	if partCount == 0 {
+		partCount = 1
+	}
+
+	uploadId, uploaded, err := s.findUpload(key)
+	if err != nil {
+		return nil, err
+	}
+	if uploadId != "" && !partsMatchFile(file, uploaded, partCount, partSize, size) {
+		s.abortUpload(key, uploadId)
+		uploadId, uploaded = "", nil
+	}
+	if uploadId == "" {
+		if uploadId, err = s.createUpload(key, checksum); err != nil {
+			return nil, err
+		}
+	}
+
+	var done int64
+	for _, part := range uploaded {
+		done += part.Size
+	}
+	if done > 0 {
+		fmt.Fprintf(os.Stderr, "Resuming upload at %d bytes\n", done)
+	}
+
+	bar := common.NewTransferBar("Uploading", size, done)
+	parts := make([]s3Part, partCount)
+	buffer := make([]byte, partSize)
+	for number := 1; number <= partCount; number++ {
+		if part, found := uploaded[number]; found {
+			parts[number-1] = part
+			continue
+		}
+		data, err := readPart(file, buffer, number, partSize, size)
+		if err == nil {
+			parts[number-1], err = s.uploadPart(key, uploadId, number, data)
+		}
+		if err != nil {
+			bar.Finish()
+			return nil, errors.Wrap(err, "upload interrupted, run the command again to resume")
+		}
+		bar.Add(len(data))
+	}
+	bar.Finish()
+
+	if err = s.completeUpload(key, uploadId, parts); err != nil {
+		return nil, err
+	}
+
+	info, err := s.Stat(key)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil || info.Size != size {
+		return nil, errors.Errorf("'%s' is incomplete in bucket '%s' after upload", key, s.bucket)
+	}
+	if info.Sha256 != "" && !strings.EqualFold(info.Sha256, checksum) {
+		return nil, errors.Errorf("checksum mismatch: local %s, storage %s", checksum, info.Sha256)
+	}
+	info.Sha256 = checksum
+	return info, nil
+}
+
+// partSizeFor grows the part size until the file fits in the maximum number of parts
+func (s *s3Storage) partSizeFor(size int64) int64 {
+	partSize := s.partSize
+	for size > partSize*S3_MAX_PARTS {
+		partSize *= 2
+	}
+	return partSize
+}
+
+func readPart(file *os.File, buffer []byte, number int, partSize, size int64) ([]byte, error) {
+	offset := int64(number-1) * partSize
+	length := partSize
+	if offset+length > size {
+		length = size - offset
+	}
+	read, err := file.ReadAt(buffer[:length], offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buffer[:read], nil
+}
+
+// partsMatchFile tells if all uploaded parts have the size and checksum of the same parts of the file
+func partsMatchFile(file *os.File, uploaded map[int]s3Part, partCount int, partSize, size int64) bool {
+	buffer := make([]byte, partSize)
+	for number, part := range uploaded {
+		if number < 1 || number > partCount {
+			return false
+		}
+		data, err := readPart(file, buffer, number, partSize, size)
+		if err != nil || int64(len(data)) != part.Size || part.ChecksumSHA256 != base64Sha256(data) {
+			return false
+		}
+	}
+	return true
+}
+
+// findUpload returns the most recent unfinished upload of the key with its parts by number
+func (s *s3Storage) findUpload(key string) (string, map[int]s3Part, error) {
+	res, err := s.do("GET", "", url.Values{"uploads": {""}, "prefix": {key}}, nil, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	var uploads s3ListUploadsResult
+	if err = parseS3Response(res, &uploads); err != nil {
+		return "", nil, err
+	}
+
+	var latest *s3Upload
+	for i, upload := range uploads.Uploads {
+		if upload.Key == key && (latest == nil || upload.Initiated.After(latest.Initiated)) {
+			latest = &uploads.Uploads[i]
+		}
+	}
+	if latest == nil {
+		return "", nil, nil
+	}
+
+	parts := make(map[int]s3Part)
+	marker := ""
+	for {
+		query := url.Values{"uploadId": {latest.UploadId}}
+		if marker != "" {
+			query.Set("part-number-marker", marker)
+		}
+		res, err = s.do("GET", key, query, nil, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		var list s3ListPartsResult
+		if err = parseS3Response(res, &list); err != nil {
+			return "", nil, err
+		}
+		for _, part := range list.Parts {
+			parts[part.PartNumber] = part
+		}
+		if !list.IsTruncated || list.NextPartNumberMarker == "" {
+			break
+		}
+		marker = list.NextPartNumberMarker
+	}
+	return latest.UploadId, parts, nil
+}
+
+// createUpload starts a multipart upload, the checksum of the whole file is kept in the object metadata
+func (s *s3Storage) createUpload(key, checksum string) (string, error) {
+	res, err := s.do("POST", key, url.Values{"uploads": {""}}, map[string]string{
+		"Content-Type":             "application/octet-stream",
+		"X-Amz-Checksum-Algorithm": "SHA256",
+		S3_SHA256_META_HEADER:      checksum,
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	var result s3InitiateUploadResult
+	if err = parseS3Response(res, &result); err != nil {
+		return "", err
+	}
+	return result.UploadId, nil
+}
+
+func (s *s3Storage) uploadPart(key, uploadId string, number int, data []byte) (s3Part, error) {
+	checksum := base64Sha256(data)
+	query := url.Values{"partNumber": {fmt.Sprint(number)}, "uploadId": {uploadId}}
+	res, err := s.do("PUT", key, query, map[string]string{S3_CHECKSUM_HEADER: checksum}, data)
+	if err != nil {
+		return s3Part{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return s3Part{}, s3Error(res)
+	}
+	return s3Part{PartNumber: number, ETag: res.Header.Get("ETag"), Size: int64(len(data)), ChecksumSHA256: checksum}, nil
+}
+
+func (s *s3Storage) completeUpload(key, uploadId string, parts []s3Part) error {
+	completed := s3CompleteUpload{Parts: make([]s3CompletedPart, len(parts))}
+	for i, part := range parts {
+		completed.Parts[i] = s3CompletedPart{part.PartNumber, part.ETag, part.ChecksumSHA256}
+	}
+	body, err := xml.Marshal(completed)
+	if err != nil {
+		return err
+	}
+	res, err := s.do("POST", key, url.Values{"uploadId": {uploadId}}, map[string]string{"Content-Type": "application/xml"}, body)
+	if err != nil {
+		return err
+	}
+	var result s3CompleteUploadResult
+	return parseS3Response(res, &result)
+}
+
+func (s *s3Storage) abortUpload(key, uploadId string) {
+	if res, err := s.do("DELETE", key, url.Values{"uploadId": {uploadId}}, nil, nil); err == nil {
+		res.Body.Close()
+	}
+}
+
+func (s *s3Storage) objectUrl(key string, query url.Values) *url.URL {
+	u := *s.endpoint
+	path := strings.TrimSuffix(u.Path, "/")
+	if s.pathStyle {
+		path += "/" + s.bucket
+	}
+	path += "/" + key
+	u.Path = path
+	u.RawPath = s3Escape(path, true)
+	u.RawQuery = s3CanonicalQuery(query)
+	return &u
+}
+
+func (s *s3Storage) do(method, key string, query url.Values, headers map[string]string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	payloadHash := S3_EMPTY_PAYLOAD_HASH
+	if body != nil {
+		reader = bytes.NewReader(body)
+		hash := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(hash[:])
+	}
+
+	req, err := http.NewRequest(method, s.objectUrl(key, query).String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	s.sign(req, payloadHash)
+	return s.client.Do(req)
+}
+
+// sign adds the AWS signature version 4 authorization to the request
+func (s *s3Storage) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format(S3_DATE_FORMAT)
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "content-md5" || lower == "range" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{date, s.region, "s3", "aws4_request"} {
+		key = hmacSha256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func base64Sha256(data []byte) string {
+	hash := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// s3Escape encodes everything except unreserved characters the way AWS signatures expect
+func s3Escape(text string, keepSlash bool) string {
+	var escaped strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (keepSlash && c == '/') {
+			escaped.WriteByte(c)
+		} else {
+			fmt.Fprintf(&escaped, "%%%02X", c)
+		}
+	}
+	return escaped.String()
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, s3Escape(name, false)+"="+s3Escape(value, false))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// s3ObjectInfo prefers the checksum set by Upload, the one computed by the server covers the whole object
+// only when it was not uploaded in parts
+func s3ObjectInfo(key string, res *http.Response) *ObjectInfo {
+	info := &ObjectInfo{Key: key, Size: res.ContentLength, ETag: res.Header.Get("ETag"), Sha256: res.Header.Get(S3_SHA256_META_HEADER)}
+	serverChecksum := res.Header.Get(S3_CHECKSUM_HEADER)
+	if info.Sha256 == "" && serverChecksum != "" && !strings.Contains(serverChecksum, "-") && res.Header.Get(S3_CHECKSUM_TYPE_HEADER) != "COMPOSITE" {
+		if decoded, err := base64.StdEncoding.DecodeString(serverChecksum); err == nil {
+			info.Sha256 = hex.EncodeToString(decoded)
+		}
+	}
+	return info
+}
+
+// parseS3Response reads the xml result, S3 may also report errors with status 200
+func parseS3Response(res *http.Response, result interface{}) error {
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK || bytes.Contains(body[:min(len(body), 100)], []byte("<Error>")) {
+		return s3ErrorFromBody(res.Status, body)
+	}
+	return xml.Unmarshal(body, result)
+}
+
+func s3Error(res *http.Response) error {
+	body, _ := io.ReadAll(res.Body)
+	return s3ErrorFromBody(res.Status, body)
+}
+
+func s3ErrorFromBody(status string, body []byte) error {
+	var s3Err s3ErrorResponse
+	if err := xml.Unmarshal(body, &s3Err); err != nil || s3Err.Code == "" {
+		return errors.New(status)
+	}
+	return errors.Errorf("%s: %s", s3Err.Code, s3Err.Message)
+}
+
+type s3ErrorResponse struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+type s3InitiateUploadResult struct {
+	UploadId string `xml:"UploadId"`
+}
+
+type s3Upload struct {
+	Key       string    `xml:"Key"`
+	UploadId  string    `xml:"UploadId"`
+	Initiated time.Time `xml:"Initiated"`
+}
+
+type s3ListUploadsResult struct {
+	Uploads []s3Upload `xml:"Upload"`
+}
+
+type s3Part struct {
+	PartNumber     int    `xml:"PartNumber"`
+	ETag           string `xml:"ETag"`
+	Size           int64  `xml:"Size"`
+	ChecksumSHA256 string `xml:"ChecksumSHA256"`
+}
+
+type s3ListPartsResult struct {
+	Parts                []s3Part `xml:"Part"`
+	IsTruncated          bool     `xml:"IsTruncated"`
+	NextPartNumberMarker string   `xml:"NextPartNumberMarker"`
+}
+
+type s3CompletedPart struct {
+	PartNumber     int    `xml:"PartNumber"`
+	ETag           string `xml:"ETag"`
+	ChecksumSHA256 string `xml:"ChecksumSHA256"`
+}
+
+type s3CompleteUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletedPart `xml:"Part"`
+}
+
+type s3CompleteUploadResult struct {
+	Key string `xml:"Key"`
+}
@@ -0,0 +1,282 @@
+package storage
+
+import (
+	"cli-enonic/internal/app/commands/common"
+	"cli-enonic/internal/app/util"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const STORAGE_PROFILE_ENV = "ENONIC_CLI_STORAGE_PROFILE"
+const DEFAULT_PROFILE_NAME = "default"
+const PROFILES_FILE = "storage.toml"
+const STAGING_DIR = "transfers"
+const STAGED_INFO_EXT = ".json"
+
+var PROFILE_FLAG = cli.StringFlag{
+	Name:  "storage-profile",
+	Usage: "Storage profile from " + PROFILES_FILE + " to use for s3:// locations, defaults to $" + STORAGE_PROFILE_ENV + " or '" + DEFAULT_PROFILE_NAME + "'",
+}
+
+// Storage is a place to keep files outside of XP, addressed by keys
+type Storage interface {
+	// Stat returns the stored object or nil if there is no object with the key
+	Stat(key string) (*ObjectInfo, error)
+	// Download saves the object into the target file, continuing a previous partial download if there is one,
+	// and verifies it against the stored checksum
+	Download(key, target string) (*ObjectInfo, error)
+	// Upload stores the file under the key, continuing an interrupted upload of the same file if there is one
+	Upload(key, file string) (*ObjectInfo, error)
+}
+
+type ObjectInfo struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+	// changes with every new version of the object, empty when the storage does not tell
+	ETag string `json:"etag,omitempty"`
+	// hex encoded, empty when the storage has no checksum of the whole object
+	Sha256 string `json:"sha256"`
+}
+
+type Profile struct {
+	// empty for AWS, e.g. http://localhost:9000 for a local MinIO
+	Endpoint  string `toml:"endpoint"`
+	Region    string `toml:"region"`
+	AccessKey string `toml:"accessKey"`
+	SecretKey string `toml:"secretKey"`
+	// size of the parts in multipart uploads in megabytes
+	PartSizeMB int `toml:"partSizeMB"`
+}
+
+type ProfilesData struct {
+	Profiles map[string]Profile `toml:"profiles"`
+}
+
+type backendFactory func(location *url.URL, profile *Profile) (Storage, error)
+
+var backends = map[string]backendFactory{
+	"s3": newS3Storage,
+}
+
+// IsStorageUrl tells if the location is an url of one of the storage backends rather than a local path
+func IsStorageUrl(location string) bool {
+	scheme, _, found := strings.Cut(location, "://")
+	_, known := backends[strings.ToLower(scheme)]
+	return found && known
+}
+
+// Open returns the storage of the location url and the key of the object in it
+func Open(c *cli.Context, location string) (Storage, string, error) {
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return nil, "", err
+	}
+	factory, found := backends[strings.ToLower(parsed.Scheme)]
+	if !found {
+		return nil, "", errors.Errorf("unsupported storage '%s'", location)
+	}
+	if parsed.Host == "" {
+		return nil, "", errors.Errorf("storage url '%s' has no bucket", location)
+	}
+
+	profile, err := loadProfile(profileName(c))
+	if err != nil {
+		return nil, "", err
+	}
+	store, err := factory(parsed, profile)
+	if err != nil {
+		return nil, "", err
+	}
+	return store, strings.TrimPrefix(parsed.Path, "/"), nil
+}
+
+// ResolveLocation appends the file name to locations pointing to a folder, like s3://bucket or s3://bucket/prefix/
+func ResolveLocation(location, fileName string) string {
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+	if parsed.Path == "" || strings.HasSuffix(parsed.Path, "/") {
+		return strings.TrimSuffix(location, "/") + "/" + fileName
+	}
+	return location
+}
+
+// BaseName returns the file name of the object in the location
+func BaseName(location string) string {
+	return path.Base(location)
+}
+
+// StagingFile returns the local file a transfer to or from the location is kept in until it is complete,
+// it stays the same between runs so that interrupted transfers can be continued
+func StagingFile(location string) string {
+	hash := sha256.Sum256([]byte(location))
+	name := hex.EncodeToString(hash[:8]) + "-" + BaseName(location)
+	return filepath.Join(util.GetEnonicHome(), STAGING_DIR, name)
+}
+
+// EnsureLocation resolves the location to an object, appending the default file name to folders,
+// and asks before overwriting an existing one unless force is set
+func EnsureLocation(c *cli.Context, location, defaultName string, force bool) string {
+	location = ResolveLocation(location, defaultName)
+	store, key, err := Open(c, location)
+	util.Fatal(err, "Could not open storage:")
+	existing, err := store.Stat(key)
+	util.Fatal(err, "Could not open storage:")
+	if existing != nil && !force && !util.PromptBool(fmt.Sprintf("'%s' already exists. Overwrite", location), false) {
+		os.Exit(1)
+	}
+	return location
+}
+
+// SaveFile runs save with the file to write to. For storage locations it is a staging file that is
+// uploaded afterwards and kept until the upload completes, so that running the command again continues the upload.
+// The source tells what is saved, a staging file of another source is never uploaded in its place.
+func SaveFile(c *cli.Context, location, source string, save func(output string)) {
+	if !IsStorageUrl(location) {
+		save(location)
+		return
+	}
+	store, key, err := Open(c, location)
+	util.Fatal(err, "Could not open storage:")
+
+	staged := StagingFile(location)
+	if _, err = os.Stat(staged); err == nil && reuseStagedFile(c, staged, location, source) {
+		fmt.Fprintf(os.Stderr, "Continuing the upload of the file saved before to '%s'\n", location)
+	} else {
+		removeStagedFile(staged)
+		util.Fatal(os.MkdirAll(filepath.Dir(staged), 0755), "Could not create staging folder:")
+		// written under another name first so that a staged file is always complete
+		tmp := staged + ".tmp"
+		save(tmp)
+		util.Fatal(writeStagedInfo(staged, &StagedInfo{Source: source, Time: time.Now()}), "Could not save file:")
+		util.Fatal(os.Rename(tmp, staged), "Could not save file:")
+	}
+
+	_, err = store.Upload(key, staged)
+	util.Fatal(err, "Could not upload to storage:")
+	removeStagedFile(staged)
+}
+
+// reuseStagedFile tells if the staging file left by a previous run holds the same source,
+// asking before it is uploaded unless force is set
+func reuseStagedFile(c *cli.Context, staged, location, source string) bool {
+	info := readStagedInfo(staged)
+	if info == nil || info.Source != source {
+		return false
+	}
+	if common.IsForceMode(c) {
+		return true
+	}
+	return util.PromptBool(fmt.Sprintf("Upload %s saved %s ago to '%s' instead of saving it again", source, util.TimeFromNow(info.Time), location), true)
+}
+
+// StagedInfo is kept next to a staging file to tell what it holds
+type StagedInfo struct {
+	Source string    `json:"source"`
+	Time   time.Time `json:"time"`
+}
+
+func readStagedInfo(staged string) *StagedInfo {
+	data, err := os.ReadFile(staged + STAGED_INFO_EXT)
+	if err != nil {
+		return nil
+	}
+	var info StagedInfo
+	if json.Unmarshal(data, &info) != nil {
+		return nil
+	}
+	return &info
+}
+
+func writeStagedInfo(staged string, info *StagedInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(staged+STAGED_INFO_EXT, data, 0640)
+}
+
+func removeStagedFile(staged string) {
+	os.Remove(staged)
+	os.Remove(staged + STAGED_INFO_EXT)
+}
+
+// FetchFile downloads files kept in a storage to a staging file and returns it with a function removing it,
+// local files are used as they are. The staging file is kept until the caller removes it, so that running
+// the command again does not download it twice.
+func FetchFile(c *cli.Context, location string) (string, func()) {
+	if !IsStorageUrl(location) {
+		return location, func() {}
+	}
+	store, key, err := Open(c, location)
+	util.Fatal(err, "Could not open storage:")
+
+	staged := StagingFile(location)
+	remove := func() {
+		os.Remove(staged)
+	}
+	if info, err := store.Stat(key); err == nil && info != nil && info.Sha256 != "" {
+		if _, statErr := os.Stat(staged); statErr == nil && common.VerifyChecksum(staged, info.Sha256) == nil {
+			return staged, remove
+		}
+	}
+
+	_, err = store.Download(key, staged)
+	util.Fatal(err, "Could not download from storage:")
+	return staged, remove
+}
+
+func profileName(c *cli.Context) string {
+	if name := c.String(PROFILE_FLAG.Name); name != "" {
+		return name
+	}
+	if name := os.Getenv(STORAGE_PROFILE_ENV); name != "" {
+		return name
+	}
+	return DEFAULT_PROFILE_NAME
+}
+
+// loadProfile reads the profile from the profiles file, credentials missing in it are taken from the standard AWS variables
+func loadProfile(name string) (*Profile, error) {
+	data := readProfilesData(filepath.Join(util.GetEnonicHome(), PROFILES_FILE))
+
+	profile, found := data.Profiles[name]
+	if !found && name != DEFAULT_PROFILE_NAME {
+		return nil, errors.Errorf("storage profile '%s' is not defined in '%s'", name, filepath.Join(util.GetEnonicHome(), PROFILES_FILE))
+	}
+	if profile.AccessKey == "" {
+		profile.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if profile.SecretKey == "" {
+		profile.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if profile.Region == "" {
+		profile.Region = os.Getenv("AWS_REGION")
+	}
+	if profile.AccessKey == "" || profile.SecretKey == "" {
+		return nil, errors.Errorf("storage profile '%s' has no credentials, set accessKey and secretKey in '%s' or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY", name, PROFILES_FILE)
+	}
+	return &profile, nil
+}
+
+func readProfilesData(path string) ProfilesData {
+	var data ProfilesData
+	if _, err := os.Stat(path); err == nil {
+		file := util.OpenOrCreateDataFile(path, true)
+		defer file.Close()
+		util.DecodeTomlFile(file, &data)
+	}
+	return data
+}
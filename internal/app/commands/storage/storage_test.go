@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+func TestIsStorageUrl(t *testing.T) {
+	for location, want := range map[string]bool{
+		"s3://bucket/dumps/a.zip": true,
+		"S3://bucket":             true,
+		"dumps/a.zip":             false,
+		"C:\\dumps\\a.zip":        false,
+		"gs://bucket/a.zip":       false,
+	} {
+		if got := IsStorageUrl(location); got != want {
+			t.Errorf("%s: got %v, want %v", location, got, want)
+		}
+	}
+}
+
+func TestResolveLocation(t *testing.T) {
+	for location, want := range map[string]string{
+		"s3://bucket":               "s3://bucket/a.zip",
+		"s3://bucket/":              "s3://bucket/a.zip",
+		"s3://bucket/dumps/":        "s3://bucket/dumps/a.zip",
+		"s3://bucket/dumps/b.zip":   "s3://bucket/dumps/b.zip",
+		"s3://bucket/dumps/nightly": "s3://bucket/dumps/nightly",
+	} {
+		if got := ResolveLocation(location, "a.zip"); got != want {
+			t.Errorf("%s: got %s, want %s", location, got, want)
+		}
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	home := t.TempDir()
+	os.MkdirAll(filepath.Join(home, ".enonic"), 0755)
+	t.Setenv("ENONIC_CLI_HOME_PATH", home)
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+	t.Setenv("AWS_REGION", "")
+
+	os.WriteFile(filepath.Join(home, ".enonic", PROFILES_FILE), []byte(`
+[profiles.minio]
+endpoint = "http://localhost:9000"
+accessKey = "minioadmin"
+secretKey = "minioadmin"
+partSizeMB = 32
+`), 0640)
+
+	minio, err := loadProfile("minio")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if minio.Endpoint != "http://localhost:9000" || minio.AccessKey != "minioadmin" || minio.PartSizeMB != 32 {
+		t.Errorf("got %+v", minio)
+	}
+
+	// the default profile does not have to be defined when the credentials are in the environment
+	aws, err := loadProfile(DEFAULT_PROFILE_NAME)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if aws.AccessKey != "env-key" || aws.SecretKey != "env-secret" || aws.Endpoint != "" {
+		t.Errorf("got %+v", aws)
+	}
+
+	if _, err = loadProfile("missing"); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected an error for an undefined profile, got %v", err)
+	}
+}
+
+func TestStagingFile(t *testing.T) {
+	a := StagingFile("s3://bucket/dumps/a.zip")
+	if a != StagingFile("s3://bucket/dumps/a.zip") {
+		t.Errorf("staging file should be the same between runs")
+	}
+	if a == StagingFile("s3://other/dumps/a.zip") || !strings.HasSuffix(a, "-a.zip") {
+		t.Errorf("unexpected staging file %s", a)
+	}
+}
+
+func TestReuseStagedFile(t *testing.T) {
+	staged := filepath.Join(t.TempDir(), "a.zip")
+	os.WriteFile(staged, []byte("old dump"), 0644)
+	fs := flag.NewFlagSet("test", 0)
+	fs.Bool("force", true, "")
+	c := cli.NewContext(nil, fs, nil)
+
+	if reuseStagedFile(c, staged, "s3://bucket/a.zip", "dump \"a\" made 2024-05-01T10:00:00Z") {
+		t.Error("a staged file without info must not be reused")
+	}
+	writeStagedInfo(staged, &StagedInfo{Source: "dump \"a\" made 2024-05-01T10:00:00Z", Time: time.Now()})
+	if reuseStagedFile(c, staged, "s3://bucket/a.zip", "dump \"a\" made 2024-05-08T10:00:00Z") {
+		t.Error("a staged file of another source must not be reused")
+	}
+	if !reuseStagedFile(c, staged, "s3://bucket/a.zip", "dump \"a\" made 2024-05-01T10:00:00Z") {
+		t.Error("a staged file of the same source should be reused")
+	}
+}
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestUnifiedDiffEqual(t *testing.T) {
+	if diff := UnifiedDiff("a", "b", "x=1\n", "x=1\n"); diff != "" {
+		t.Errorf("expected no diff, got %q", diff)
+	}
+}
+
+func TestUnifiedDiffChangedLine(t *testing.T) {
+	from := "a=1\nb=2\nc=3\n"
+	to := "a=1\nb=20\nc=3\nd=4\n"
+
+	expected := "--- current\n+++ new\n" +
+		"@@ -1,3 +1,4 @@\n" +
+		" a=1\n" +
+		"-b=2\n" +
+		"+b=20\n" +
+		" c=3\n" +
+		"+d=4\n"
+
+	if diff := UnifiedDiff("current", "new", from, to); diff != expected {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", diff, expected)
+	}
+}
+
+func TestUnifiedDiffSeparateHunks(t *testing.T) {
+	from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	to := "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n"
+
+	expected := "--- a\n+++ b\n" +
+		"@@ -1,4 +1,4 @@\n" +
+		"-1\n" +
+		"+one\n" +
+		" 2\n" +
+		" 3\n" +
+		" 4\n" +
+		"@@ -7,4 +7,4 @@\n" +
+		" 7\n" +
+		" 8\n" +
+		" 9\n" +
+		"-10\n" +
+		"+ten\n"
+
+	if diff := UnifiedDiff("a", "b", from, to); diff != expected {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", diff, expected)
+	}
+}
+
+func TestUnifiedDiffNewFile(t *testing.T) {
+	expected := "--- a\n+++ b\n" +
+		"@@ -0,0 +1,2 @@\n" +
+		"+x=1\n" +
+		"+y=2\n"
+
+	if diff := UnifiedDiff("a", "b", "", "x=1\r\ny=2"); diff != expected {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", diff, expected)
+	}
+}